@@ -15,16 +15,85 @@
 package ship
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/xgfone/ship/v5/binder"
 )
 
+var (
+	charsetLock     sync.RWMutex
+	charsetDecoders = make(map[string]func(io.Reader) (io.Reader, error), 4)
+)
+
+// RegisterCharsetDecoder registers the decoder to transcode the request body
+// from the charset to UTF-8, which is used by JSONBinder and XMLBinder
+// and will override the existed decoder.
+//
+// The charsets "utf-8" and "us-ascii" are supported by default.
+// For others, you may register them by "golang.org/x/text/encoding",
+// for example,
+//
+//    enc, _ := htmlindex.Get("gbk")
+//    RegisterCharsetDecoder("gbk", func(r io.Reader) (io.Reader, error) {
+//        return enc.NewDecoder().Reader(r), nil
+//    })
+func RegisterCharsetDecoder(charset string, decoder func(io.Reader) (io.Reader, error)) {
+	if charset == "" {
+		panic("RegisterCharsetDecoder: the charset must not be empty")
+	} else if decoder == nil {
+		panic("RegisterCharsetDecoder: the charset decoder must not be nil")
+	}
+
+	charsetLock.Lock()
+	charsetDecoders[strings.ToLower(charset)] = decoder
+	charsetLock.Unlock()
+}
+
+// GetCharsetDecoder returns the registered decoder of the charset.
+//
+// Return nil if the decoder does not exist.
+func GetCharsetDecoder(charset string) func(io.Reader) (io.Reader, error) {
+	charsetLock.RLock()
+	decoder := charsetDecoders[strings.ToLower(charset)]
+	charsetLock.RUnlock()
+	return decoder
+}
+
+func isUTF8Charset(charset string) bool {
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return true
+	default:
+		return false
+	}
+}
+
+// decodeCharset returns the reader of the request body transcoded to UTF-8
+// by the charset of the request header "Content-Type".
+//
+// Return ErrUnsupportedMediaType if the charset is not supported.
+func decodeCharset(r *http.Request) (io.Reader, bool, error) {
+	charset := getCharset(r.Header.Get(HeaderContentType))
+	if isUTF8Charset(charset) {
+		return r.Body, false, nil
+	}
+
+	if decoder := GetCharsetDecoder(charset); decoder != nil {
+		reader, err := decoder(r.Body)
+		return reader, true, err
+	}
+
+	return nil, false, ErrUnsupportedMediaType.Newf("unsupported charset '%s'", charset)
+}
+
 // Binder is the interface to bind the value dst to req.
 type Binder interface {
 	// Bind parses the data from http.Request to dst.
@@ -89,21 +158,169 @@ func (mb *MuxBinder) Bind(dst interface{}, req *http.Request) error {
 	return ErrUnsupportedMediaType.Newf("not support Content-Type '%s'", ct)
 }
 
+// DefaultJSONMaxDepth is the default maximum nesting depth of the JSON
+// request body decoded by JSONBinder.
+const DefaultJSONMaxDepth = 1000
+
+// JSONBinderOption is used to configure the binder returned by JSONBinder.
+type JSONBinderOption func(*jsonBinderConfig)
+
+type jsonBinderConfig struct {
+	maxDepth int
+}
+
+// WithMaxDepth returns a JSONBinder option to set the maximum nesting depth
+// of the objects and arrays in the JSON request body. If exceeded,
+// abort decoding and return ErrBadRequest. If maxDepth is equal to
+// or less than 0, the nesting depth is not limited.
+//
+// Default: DefaultJSONMaxDepth
+func WithMaxDepth(maxDepth int) JSONBinderOption {
+	return func(c *jsonBinderConfig) { c.maxDepth = maxDepth }
+}
+
 // JSONBinder returns a binder to bind the data to the request body as JSON.
-func JSONBinder() Binder {
+//
+// The request body is transcoded to UTF-8 by the charset of the request
+// header "Content-Type" if it is registered by RegisterCharsetDecoder,
+// or return ErrUnsupportedMediaType.
+//
+// The nesting depth of the JSON request body is limited to guard against
+// the deeply nested JSON consuming the stack and CPU, which is
+// DefaultJSONMaxDepth by default and may be changed by WithMaxDepth.
+func JSONBinder(options ...JSONBinderOption) Binder {
+	conf := jsonBinderConfig{maxDepth: DefaultJSONMaxDepth}
+	for _, option := range options {
+		option(&conf)
+	}
+
 	return BinderFunc(func(v interface{}, r *http.Request) (err error) {
 		if r.ContentLength > 0 {
-			err = json.NewDecoder(r.Body).Decode(v)
+			var body io.Reader
+			if body, _, err = decodeCharset(r); err == nil {
+				if conf.maxDepth > 0 {
+					dr := &jsonDepthReader{r: body, max: conf.maxDepth}
+					if err = json.NewDecoder(dr).Decode(v); dr.err != nil {
+						err = dr.err
+					}
+				} else {
+					err = json.NewDecoder(body).Decode(v)
+				}
+			}
 		}
 		return
 	})
 }
 
+// jsonDepthReader scans the JSON stream while reading it, and returns
+// ErrBadRequest once the nesting depth of the objects and arrays exceeds max.
+//
+// The error is also kept in err, because the JSON decoder may replace
+// the read error with its own, such as io.ErrUnexpectedEOF.
+type jsonDepthReader struct {
+	r   io.Reader
+	max int
+	err error
+
+	depth    int
+	inString bool
+	escaped  bool
+}
+
+func (r *jsonDepthReader) Read(p []byte) (n int, err error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n, err = r.r.Read(p)
+	for i, b := range p[:n] {
+		switch {
+		case r.escaped:
+			r.escaped = false
+		case r.inString:
+			switch b {
+			case '\\':
+				r.escaped = true
+			case '"':
+				r.inString = false
+			}
+		case b == '"':
+			r.inString = true
+		case b == '{' || b == '[':
+			if r.depth++; r.depth > r.max {
+				r.err = ErrBadRequest.Newf("the JSON nesting depth exceeds %d", r.max)
+				return i, r.err
+			}
+		case b == '}' || b == ']':
+			r.depth--
+		}
+	}
+	return
+}
+
+// NDJSONHandler is the callback to handle each record of the NDJSON
+// (newline-delimited JSON) request body, which is used as the bound value
+// of NDJSONBinder.
+type NDJSONHandler func(record json.RawMessage) error
+
+// NDJSONBinder returns a binder to decode the request body as NDJSON
+// record by record, and call the bound value, which must be NDJSONHandler,
+// for each record. So the whole request body is not buffered in memory.
+//
+// If the request body is malformed, return ErrBadRequest. And if the handler
+// returns an error, stop decoding and return it.
+func NDJSONBinder() Binder {
+	return BinderFunc(func(v interface{}, r *http.Request) (err error) {
+		handler, ok := v.(NDJSONHandler)
+		if !ok {
+			if f, _ok := v.(func(json.RawMessage) error); _ok {
+				handler, ok = f, true
+			}
+		}
+		if !ok {
+			return ErrInternalServerError.Newf("NDJSONBinder: unsupported type '%T'", v)
+		}
+
+		dec := json.NewDecoder(r.Body)
+		for {
+			var record json.RawMessage
+			if err = dec.Decode(&record); err == io.EOF {
+				return nil
+			} else if err != nil {
+				if _, ok := err.(HTTPServerError); !ok {
+					err = ErrBadRequest.New(err)
+				}
+				return
+			} else if err = handler(record); err != nil {
+				return
+			}
+		}
+	})
+}
+
 // XMLBinder returns a binder to bind the data to the request body as XML.
+//
+// The request body is transcoded to UTF-8 by the charset of the request
+// header "Content-Type" or the encoding declaration of XML if it is
+// registered by RegisterCharsetDecoder, or return ErrUnsupportedMediaType.
 func XMLBinder() Binder {
 	return BinderFunc(func(v interface{}, r *http.Request) (err error) {
 		if r.ContentLength > 0 {
-			err = xml.NewDecoder(r.Body).Decode(v)
+			body, decoded, _err := decodeCharset(r)
+			if _err != nil {
+				return _err
+			}
+
+			dec := xml.NewDecoder(body)
+			dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+				if decoded || isUTF8Charset(charset) {
+					return input, nil
+				} else if decoder := GetCharsetDecoder(charset); decoder != nil {
+					return decoder(input)
+				}
+				return nil, ErrUnsupportedMediaType.Newf("unsupported charset '%s'", charset)
+			}
+			err = dec.Decode(v)
 		}
 		return
 	})
@@ -139,6 +356,20 @@ func FormBinder(maxMemory int64, tag ...string) Binder {
 	})
 }
 
+// ctxReadCloser is a reader to return the error of the context
+// once the context is done.
+type ctxReadCloser struct {
+	ctx context.Context
+	io.ReadCloser
+}
+
+func (r ctxReadCloser) Read(p []byte) (n int, err error) {
+	if err = r.ctx.Err(); err == nil {
+		n, err = r.ReadCloser.Read(p)
+	}
+	return
+}
+
 func bindQuery(dst interface{}, src url.Values) error {
 	return binder.BindURLValues(dst, src, "query")
 }
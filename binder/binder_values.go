@@ -18,8 +18,11 @@ import (
 	"errors"
 	"fmt"
 	"mime/multipart"
+	"net/http"
+	"net/textproto"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -32,6 +35,14 @@ type BindUnmarshaler interface {
 	UnmarshalBind(param string) error
 }
 
+// MaxSliceIndex is the maximum index of the slice of structs to be bound,
+// which is used to avoid allocating a huge slice by the malicious client.
+var MaxSliceIndex = 1000
+
+// TimeFormats is the default layouts to parse the value of the time.Time field
+// in turn if the field does not have the struct tag "time_format".
+var TimeFormats = []string{time.RFC3339}
+
 // BindURLValuesAndFiles parses the data and assign to the pointer ptr to a struct.
 //
 // Notice: tag is the name of the struct tag. such as "form", "query", etc.
@@ -52,20 +63,24 @@ type BindUnmarshaler interface {
 //   - string
 //   - float32
 //   - float64
-//   - time.Time     // use time.Time.UnmarshalText(), so only support RFC3339 format
+//   - time.Time     // use the struct tag "time_format" or TimeFormats as the layout
 //   - time.Duration // use time.ParseDuration()
 // And any pointer to the type above, and
 //   - *multipart.FileHeader
 //   - []*multipart.FileHeader
 //   - interface { UnmarshalBind(param string) error }
+//   - []struct or []*struct // the key is like "name[index].subfield"
+//   - map[string]T          // the key is like "name[key]", and T is the type above
 //
+// For the slice of structs, the index must be in [0, MaxSliceIndex],
+// for example, "items[0].name=a&items[1].name=b".
 func BindURLValuesAndFiles(ptr interface{}, data url.Values,
 	files map[string][]*multipart.FileHeader, tag string) error {
 	value := reflect.ValueOf(ptr)
 	if value.Kind() != reflect.Ptr {
 		return fmt.Errorf("%T is not a pointer", ptr)
 	}
-	return bindURLValues(value.Elem(), files, data, tag)
+	return bindURLValues(value.Elem(), files, data, tag, false)
 }
 
 // BindURLValues is equal to BindURLValuesAndFiles(ptr, data, nil, tag).
@@ -73,8 +88,176 @@ func BindURLValues(ptr interface{}, data url.Values, tag string) error {
 	return BindURLValuesAndFiles(ptr, data, nil, tag)
 }
 
+// bindURLValuesWithModifiers is the same as BindURLValues, but supports
+// the tag modifiers like `tag:"name,required"`, and returns a MissingKeysError
+// if the key of the non-pointer field with the modifier "required" is missing.
+func bindURLValuesWithModifiers(ptr interface{}, data url.Values,
+	fields map[string]bool, tag string) error {
+	var missings []string
+	for name, required := range fields {
+		if _, ok := data[name]; required && !ok {
+			missings = append(missings, name)
+		}
+	}
+	if len(missings) > 0 {
+		sort.Strings(missings)
+		return MissingKeysError(missings)
+	}
+
+	value := reflect.ValueOf(ptr)
+	if value.Kind() != reflect.Ptr {
+		return fmt.Errorf("%T is not a pointer", ptr)
+	}
+	return bindURLValues(value.Elem(), nil, data, tag, true)
+}
+
+// BindHeader binds the http header into the struct that ptr points to,
+// which is the same as BindURLValues, but the field name from the tag
+// is matched with the header key case-insensitively.
+//
+// For the multi-valued header, bind it into the slice field.
+//
+// Like BindCookies, it returns a MissingKeysError if the header of
+// the non-pointer field with the tag modifier "required" is missing.
+func BindHeader(ptr interface{}, header http.Header, tag string) error {
+	typ := reflect.TypeOf(ptr)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return fmt.Errorf("%T is not a pointer to struct", ptr)
+	}
+
+	fields := make(map[string]bool, typ.NumField())
+	collectFieldNames(typ, tag, true, fields)
+
+	data := make(url.Values, len(fields))
+	for name := range fields {
+		if values := header[textproto.CanonicalMIMEHeaderKey(name)]; len(values) > 0 {
+			data[name] = values
+		}
+	}
+
+	return bindURLValuesWithModifiers(ptr, data, fields, tag)
+}
+
+// BindCookies binds the cookies into the struct that ptr points to,
+// which is the same as BindURLValues, but returns a MissingKeysError
+// if the cookie of the non-pointer field with the tag modifier "required",
+// such as `cookie:"session,required"`, is missing.
+//
+// If there are more than one cookie with the same name, use the first one.
+func BindCookies(ptr interface{}, cookies []*http.Cookie, tag string) error {
+	typ := reflect.TypeOf(ptr)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return fmt.Errorf("%T is not a pointer to struct", ptr)
+	}
+
+	data := make(url.Values, len(cookies))
+	for _, cookie := range cookies {
+		if _, ok := data[cookie.Name]; !ok {
+			data[cookie.Name] = []string{cookie.Value}
+		}
+	}
+
+	fields := make(map[string]bool, typ.NumField())
+	collectFieldNames(typ, tag, true, fields)
+	return bindURLValuesWithModifiers(ptr, data, fields, tag)
+}
+
+// MissingKeysError is returned when the required keys are missing.
+type MissingKeysError []string
+
+func (e MissingKeysError) Error() string {
+	return fmt.Sprintf("missing keys: %s", strings.Join(e, ", "))
+}
+
+// UnknownKeysError is returned by BindURLValuesStrict when the data contains
+// the keys which are not bound to any field of the struct.
+type UnknownKeysError []string
+
+func (e UnknownKeysError) Error() string {
+	return fmt.Sprintf("unknown keys: %s", strings.Join(e, ", "))
+}
+
+// BindURLValuesStrict is the same as BindURLValues, but returns
+// an UnknownKeysError if data contains the keys which are not bound
+// to any field of the struct.
+func BindURLValuesStrict(ptr interface{}, data url.Values, tag string) error {
+	if keys := UnknownKeys(ptr, data, tag); len(keys) > 0 {
+		return UnknownKeysError(keys)
+	}
+	return BindURLValues(ptr, data, tag)
+}
+
+// UnknownKeys returns the sorted keys of data which are not bound
+// to any field of the struct that ptr points to.
+func UnknownKeys(ptr interface{}, data url.Values, tag string) (keys []string) {
+	typ := reflect.TypeOf(ptr)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return
+	}
+
+	fields := make(map[string]bool, typ.NumField())
+	collectFieldNames(typ, tag, false, fields)
+	for key := range data {
+		name := key
+		if index := strings.IndexByte(key, '['); index > 0 {
+			name = key[:index]
+		}
+
+		if _, ok := fields[name]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return
+}
+
+// collectFieldNames collects the names of the fields, and the value is true
+// if the field is a non-pointer and has the tag modifier "required".
+func collectFieldNames(typ reflect.Type, tag string, modifiers bool, names map[string]bool) {
+	for i, num := 0, typ.NumField(); i < num; i++ {
+		field := typ.Field(i)
+		fieldName, required := getFieldName(field, tag, modifiers)
+		if fieldName == "-" {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectFieldNames(field.Type, tag, modifiers, names)
+		} else if field.PkgPath == "" {
+			names[fieldName] = required && field.Type.Kind() != reflect.Ptr
+		}
+	}
+}
+
+// getFieldName returns the name of the field from the tag.
+//
+// If modifiers is true, the tag may have the modifier "required"
+// like `tag:"name,required"`, which is only supported by BindHeader
+// and BindCookies. Or, the whole tag value is the name.
+func getFieldName(field reflect.StructField, tag string, modifiers bool) (name string, required bool) {
+	name = field.Tag.Get(tag)
+	if index := strings.IndexByte(name, ','); modifiers && index > -1 {
+		required = strings.TrimSpace(name[index+1:]) == "required"
+		name = name[:index]
+	}
+
+	if name = strings.TrimSpace(name); name == "" {
+		name = field.Name
+	}
+	return
+}
+
 func bindURLValues(val reflect.Value, files map[string][]*multipart.FileHeader,
-	data url.Values, tag string) (err error) {
+	data url.Values, tag string, modifiers bool) (err error) {
 	valType := val.Type()
 	if valType.Kind() != reflect.Struct {
 		return errors.New("binding element must be a struct")
@@ -82,18 +265,15 @@ func bindURLValues(val reflect.Value, files map[string][]*multipart.FileHeader,
 
 	for i, num := 0, valType.NumField(); i < num; i++ {
 		field := valType.Field(i)
-		fieldName := field.Tag.Get(tag)
-		switch fieldName = strings.TrimSpace(fieldName); fieldName {
-		case "":
-			fieldName = field.Name
-		case "-":
+		fieldName, _ := getFieldName(field, tag, modifiers)
+		if fieldName == "-" {
 			continue
 		}
 
 		fieldValue := val.Field(i)
 		fieldKind := fieldValue.Kind()
 		if field.Anonymous && fieldKind == reflect.Struct {
-			if err = bindURLValues(fieldValue, files, data, tag); err != nil {
+			if err = bindURLValues(fieldValue, files, data, tag, modifiers); err != nil {
 				return err
 			}
 			continue
@@ -101,6 +281,20 @@ func bindURLValues(val reflect.Value, files map[string][]*multipart.FileHeader,
 			continue
 		}
 
+		switch {
+		case fieldKind == reflect.Slice && isStructType(field.Type.Elem()):
+			if err = bindStructSlice(fieldValue, data, fieldName, tag, modifiers); err != nil {
+				return
+			}
+			continue
+
+		case fieldKind == reflect.Map && field.Type.Key().Kind() == reflect.String:
+			if err = bindMap(fieldValue, data, fieldName); err != nil {
+				return
+			}
+			continue
+		}
+
 		inputValue, exists := data[fieldName]
 		if !exists {
 			if fhs := files[fieldName]; len(fhs) > 0 {
@@ -116,19 +310,20 @@ func bindURLValues(val reflect.Value, files map[string][]*multipart.FileHeader,
 			continue
 		}
 
+		timeFormat := field.Tag.Get("time_format")
 		if fieldKind == reflect.Slice {
 			num := len(inputValue)
 			kind := field.Type.Elem().Kind()
 			slice := reflect.MakeSlice(field.Type, num, num)
 			for j := 0; j < num; j++ {
-				err = setWithProperType(kind, slice.Index(j), inputValue[j])
+				err = setWithProperType(kind, slice.Index(j), inputValue[j], timeFormat)
 				if err != nil {
 					return
 				}
 			}
 			fieldValue.Set(slice)
 		} else {
-			err = setWithProperType(fieldKind, fieldValue, inputValue[0])
+			err = setWithProperType(fieldKind, fieldValue, inputValue[0], timeFormat)
 			if err != nil {
 				return
 			}
@@ -138,6 +333,106 @@ func bindURLValues(val reflect.Value, files map[string][]*multipart.FileHeader,
 	return
 }
 
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+)
+
+func isStructType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType && t != fileHeaderType &&
+		!reflect.PtrTo(t).Implements(binderType)
+}
+
+// bindStructSlice binds the values whose keys are like "name[index].subkey"
+// into the slice of structs.
+func bindStructSlice(val reflect.Value, data url.Values, name, tag string, modifiers bool) error {
+	prefix := name + "["
+	var elems map[int]url.Values
+	maxIndex := -1
+	for key, values := range data {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		end := strings.IndexByte(key, ']')
+		if end < 0 || end+1 >= len(key) || key[end+1] != '.' {
+			return fmt.Errorf("invalid key '%s'", key)
+		}
+
+		index, err := strconv.Atoi(key[len(prefix):end])
+		if err != nil || index < 0 {
+			return fmt.Errorf("invalid index in the key '%s'", key)
+		} else if index > MaxSliceIndex {
+			return fmt.Errorf("the index in the key '%s' exceeds the maximum %d",
+				key, MaxSliceIndex)
+		}
+
+		if elems == nil {
+			elems = make(map[int]url.Values, 4)
+		}
+		if elems[index] == nil {
+			elems[index] = make(url.Values, 4)
+		}
+		elems[index][key[end+2:]] = values
+		if index > maxIndex {
+			maxIndex = index
+		}
+	}
+
+	if maxIndex < 0 {
+		return nil
+	}
+
+	elemType := val.Type().Elem()
+	slice := reflect.MakeSlice(val.Type(), maxIndex+1, maxIndex+1)
+	for i := 0; i <= maxIndex; i++ {
+		elem := slice.Index(i)
+		if elemType.Kind() == reflect.Ptr {
+			elem.Set(reflect.New(elemType.Elem()))
+			elem = elem.Elem()
+		}
+
+		if values, ok := elems[i]; ok {
+			if err := bindURLValues(elem, nil, values, tag, modifiers); err != nil {
+				return err
+			}
+		}
+	}
+
+	val.Set(slice)
+	return nil
+}
+
+// bindMap binds the values whose keys are like "name[key]" into the map.
+func bindMap(val reflect.Value, data url.Values, name string) (err error) {
+	prefix := name + "["
+	mapType := val.Type()
+	elemType := mapType.Elem()
+	for key, values := range data {
+		if len(values) == 0 || !strings.HasPrefix(key, prefix) ||
+			key[len(key)-1] != ']' {
+			continue
+		}
+
+		if val.IsNil() {
+			val.Set(reflect.MakeMap(mapType))
+		}
+
+		elem := reflect.New(elemType).Elem()
+		err = setWithProperType(elemType.Kind(), elem, values[0], "")
+		if err != nil {
+			return
+		}
+
+		mapKey := reflect.ValueOf(key[len(prefix) : len(key)-1]).Convert(mapType.Key())
+		val.SetMapIndex(mapKey, elem)
+	}
+	return
+}
+
 var binderType = reflect.TypeOf((*BindUnmarshaler)(nil)).Elem()
 
 func bindUnmarshaler(kind reflect.Kind, val reflect.Value, value string) (ok bool, err error) {
@@ -154,7 +449,7 @@ func bindUnmarshaler(kind reflect.Kind, val reflect.Value, value string) (ok boo
 	return false, nil
 }
 
-func setWithProperType(kind reflect.Kind, value reflect.Value, input string) error {
+func setWithProperType(kind reflect.Kind, value reflect.Value, input, timeFormat string) error {
 	if kind == reflect.Ptr && value.IsNil() {
 		value.Set(reflect.New(value.Type().Elem()))
 	} else if kind == reflect.Interface && value.IsNil() {
@@ -168,7 +463,7 @@ func setWithProperType(kind reflect.Kind, value reflect.Value, input string) err
 	switch kind {
 	case reflect.Ptr:
 		value = value.Elem()
-		return setWithProperType(value.Kind(), value, input)
+		return setWithProperType(value.Kind(), value, input, timeFormat)
 	case reflect.Int:
 		return setIntField(input, 0, value)
 	case reflect.Int8:
@@ -206,16 +501,35 @@ func setWithProperType(kind reflect.Kind, value reflect.Value, input string) err
 		value.SetString(input)
 	default:
 		if _, ok := value.Interface().(time.Time); ok {
-			if input == "" {
-				return nil
-			}
-			return value.Addr().Interface().(*time.Time).UnmarshalText([]byte(input))
+			return setTimeField(input, timeFormat, value)
 		}
 		return fmt.Errorf("unknown field type '%T'", value.Interface())
 	}
 	return nil
 }
 
+func setTimeField(value, format string, field reflect.Value) (err error) {
+	if value == "" {
+		return nil
+	}
+
+	var t time.Time
+	if format != "" {
+		t, err = time.Parse(format, value)
+	} else {
+		for _, format = range TimeFormats {
+			if t, err = time.Parse(format, value); err == nil {
+				break
+			}
+		}
+	}
+
+	if err == nil {
+		field.Set(reflect.ValueOf(t))
+	}
+	return
+}
+
 func setIntField(value string, bitSize int, field reflect.Value) error {
 	if value == "" {
 		return nil
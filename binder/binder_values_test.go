@@ -166,3 +166,148 @@ func TestBindURLValues(t *testing.T) {
 		t.Error(*v.Slice1[0], *v.Slice1[1])
 	}
 }
+
+func TestBindURLValuesStrict(t *testing.T) {
+	type Embed struct {
+		Embed string `query:"embed"`
+	}
+	type T struct {
+		Embed
+		Name    string `query:"name"`
+		Ignore  string `query:"-"`
+		private string
+	}
+
+	var v T
+	data := url.Values{"name": []string{"abc"}, "embed": []string{"xyz"}}
+	if err := BindURLValuesStrict(&v, data, "query"); err != nil {
+		t.Error(err)
+	} else if v.Name != "abc" || v.Embed.Embed != "xyz" {
+		t.Errorf("unexpected value %+v", v)
+	}
+
+	data = url.Values{"name": []string{"abc"}, "Ignore": []string{"a"},
+		"private": []string{"b"}, "-": []string{"c"}}
+	err := BindURLValuesStrict(&v, data, "query")
+	if keys, ok := err.(UnknownKeysError); !ok {
+		t.Errorf("expect UnknownKeysError, but got %v", err)
+	} else if expect := []string{"-", "Ignore", "private"}; !reflect.DeepEqual([]string(keys), expect) {
+		t.Errorf("expect keys %v, but got %v", expect, keys)
+	}
+	_ = v.private
+}
+
+func TestBindURLValuesTagModifiers(t *testing.T) {
+	type T struct {
+		Name string `query:"name,required"`
+	}
+
+	var v T
+	data := url.Values{"name,required": []string{"abc"}}
+	if err := BindURLValues(&v, data, "query"); err != nil {
+		t.Error(err)
+	} else if v.Name != "abc" {
+		t.Errorf("expect the whole tag value as the name, but got %+v", v)
+	}
+
+	v = T{}
+	data = url.Values{"name": []string{"abc"}}
+	if err := BindURLValues(&v, data, "query"); err != nil {
+		t.Error(err)
+	} else if v.Name != "" {
+		t.Errorf("unexpected the tag modifiers for BindURLValues: %+v", v)
+	}
+}
+
+func TestBindURLValuesTimeFormat(t *testing.T) {
+	type T struct {
+		Date  time.Time  `query:"date" time_format:"2006-01-02"`
+		Time  time.Time  `query:"time"`
+		Ptr   *time.Time `query:"ptr" time_format:"2006-01-02 15:04"`
+		Times []time.Time
+	}
+
+	defer func(formats []string) { TimeFormats = formats }(TimeFormats)
+	TimeFormats = []string{time.RFC3339, "2006-01-02"}
+
+	var v T
+	data := url.Values{
+		"date":  []string{"2022-02-10"},
+		"time":  []string{"2022-02-11"},
+		"ptr":   []string{"2022-02-12 14:12"},
+		"Times": []string{"2022-02-13T14:12:02Z", "2022-02-14"},
+	}
+	if err := BindURLValues(&v, data, "query"); err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := time.Date(2022, 2, 10, 0, 0, 0, 0, time.UTC); !v.Date.Equal(expect) {
+		t.Errorf("expect date '%s', but got '%s'", expect, v.Date)
+	}
+	if expect := time.Date(2022, 2, 11, 0, 0, 0, 0, time.UTC); !v.Time.Equal(expect) {
+		t.Errorf("expect time '%s', but got '%s'", expect, v.Time)
+	}
+	if expect := time.Date(2022, 2, 12, 14, 12, 0, 0, time.UTC); v.Ptr == nil || !v.Ptr.Equal(expect) {
+		t.Errorf("expect ptr '%s', but got '%v'", expect, v.Ptr)
+	}
+	if len(v.Times) != 2 {
+		t.Errorf("expect %d times, but got %d", 2, len(v.Times))
+	} else if expect := time.Date(2022, 2, 14, 0, 0, 0, 0, time.UTC); !v.Times[1].Equal(expect) {
+		t.Errorf("expect time '%s', but got '%s'", expect, v.Times[1])
+	}
+
+	data = url.Values{"date": []string{"2022-02-10T14:12:02Z"}}
+	if err := BindURLValues(&v, data, "query"); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
+
+func TestBindURLValuesNested(t *testing.T) {
+	type Item struct {
+		Name  string `query:"name"`
+		Count int    `query:"count"`
+	}
+	type T struct {
+		Items  []Item            `query:"items"`
+		PItems []*Item           `query:"pitems"`
+		Labels map[string]string `query:"labels"`
+		Counts map[string]int    `query:"counts"`
+	}
+
+	var v T
+	data := url.Values{
+		"items[0].name":  []string{"a"},
+		"items[0].count": []string{"1"},
+		"items[2].name":  []string{"c"},
+		"pitems[0].name": []string{"p"},
+		"labels[k1]":     []string{"v1"},
+		"labels[k2]":     []string{"v2"},
+		"counts[c]":      []string{"3"},
+	}
+	if err := BindURLValues(&v, data, "query"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectItems := []Item{{Name: "a", Count: 1}, {}, {Name: "c"}}
+	if !reflect.DeepEqual(v.Items, expectItems) {
+		t.Errorf("expect items %+v, but got %+v", expectItems, v.Items)
+	}
+	if len(v.PItems) != 1 || v.PItems[0].Name != "p" {
+		t.Errorf("unexpected pitems %+v", v.PItems)
+	}
+	if expect := map[string]string{"k1": "v1", "k2": "v2"}; !reflect.DeepEqual(v.Labels, expect) {
+		t.Errorf("expect labels %v, but got %v", expect, v.Labels)
+	}
+	if expect := map[string]int{"c": 3}; !reflect.DeepEqual(v.Counts, expect) {
+		t.Errorf("expect counts %v, but got %v", expect, v.Counts)
+	}
+
+	if keys := UnknownKeys(&v, data, "query"); len(keys) != 0 {
+		t.Errorf("unexpected unknown keys %v", keys)
+	}
+
+	data = url.Values{"items[1001].name": []string{"a"}}
+	if err := BindURLValues(&v, data, "query"); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
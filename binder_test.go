@@ -16,11 +16,15 @@ package ship
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -74,3 +78,181 @@ func testBinder(t *testing.T, mb *MuxBinder, ct string, body io.Reader) {
 		t.Errorf("expect '%v', but got '%v'", expect, result)
 	}
 }
+
+type cancelReader struct {
+	cancel func()
+	data   []byte
+}
+
+func (r *cancelReader) Read(p []byte) (n int, err error) {
+	r.cancel()
+	n = copy(p, r.data[:1])
+	r.data = r.data[n:]
+	return
+}
+
+func TestContextBindCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	body := &cancelReader{cancel: cancel, data: []byte(`{"username":"xgfone"}`)}
+	req, _ := NewRequestWithContext(ctx, http.MethodPost, "http://127.0.0.1", body)
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	req.ContentLength = int64(len(body.data))
+
+	s := Default()
+	c := s.AcquireContext(req, nil)
+	defer s.ReleaseContext(c)
+
+	var result binderTestInfo
+	if err := c.Bind(&result); err != context.Canceled {
+		t.Errorf("expect the error '%v', but got '%v'", context.Canceled, err)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	s := Default()
+	s.Route("/").POST(func(c *Context) error {
+		var records []map[string]int
+		err := c.Bind(NDJSONHandler(func(record json.RawMessage) error {
+			var r map[string]int
+			if err := json.Unmarshal(record, &r); err != nil {
+				return err
+			}
+			records = append(records, r)
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+
+		w, err := c.NDJSON(200)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			r["id"] *= 10
+			if err = w.Write(r); err != nil {
+				return err
+			}
+		}
+		return w.Close()
+	})
+
+	body := bytes.NewBufferString("{\"id\":1}\n{\"id\":2}\n\n{\"id\":3}\n")
+	req, _ := http.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set(HeaderContentType, MIMEApplicationNDJSON)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	expect := "{\"id\":10}\n{\"id\":20}\n{\"id\":30}\n"
+	if rec.Code != 200 {
+		t.Errorf("expect status code %d, but got %d: %s", 200, rec.Code, rec.Body.String())
+	} else if body := rec.Body.String(); body != expect {
+		t.Errorf("expect body '%s', but got '%s'", expect, body)
+	} else if ct := rec.Header().Get(HeaderContentType); ct != MIMEApplicationNDJSON {
+		t.Errorf("expect Content-Type '%s', but got '%s'", MIMEApplicationNDJSON, ct)
+	}
+
+	body = bytes.NewBufferString("{\"id\":1}\n{\"id\":")
+	req, _ = http.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set(HeaderContentType, MIMEApplicationNDJSON)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != 400 {
+		t.Errorf("expect status code %d, but got %d", 400, rec.Code)
+	}
+}
+
+func TestBinderCharset(t *testing.T) {
+	latin1 := func(r io.Reader) (io.Reader, error) {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+
+	bind := func(b Binder, ct string, body []byte) (binderTestInfo, error) {
+		var result binderTestInfo
+		req, _ := http.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set(HeaderContentType, ct)
+		return result, b.Bind(&result, req)
+	}
+
+	jsonbody := []byte("{\"username\":\"caf\xe9\"}")
+	xmlbody := []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>" +
+		"<binderTestInfo><username>caf\xe9</username></binderTestInfo>")
+
+	_, err := bind(JSONBinder(), "application/json; charset=ISO-8859-1", jsonbody)
+	if se, ok := err.(HTTPServerError); !ok || se.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expect a 415 error, but got '%v'", err)
+	}
+	if _, err = bind(XMLBinder(), MIMEApplicationXML, xmlbody); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+
+	RegisterCharsetDecoder("ISO-8859-1", latin1)
+	defer func() {
+		charsetLock.Lock()
+		delete(charsetDecoders, "iso-8859-1")
+		charsetLock.Unlock()
+	}()
+
+	result, err := bind(JSONBinder(), "application/json; charset=ISO-8859-1", jsonbody)
+	if err != nil {
+		t.Error(err)
+	} else if result.Username != "café" {
+		t.Errorf("expect username '%s', but got '%s'", "café", result.Username)
+	}
+
+	result, err = bind(XMLBinder(), MIMEApplicationXML, xmlbody)
+	if err != nil {
+		t.Error(err)
+	} else if result.Username != "café" {
+		t.Errorf("expect username '%s', but got '%s'", "café", result.Username)
+	}
+
+	result, err = bind(JSONBinder(), "application/json; charset=utf-8", []byte(`{"username":"café"}`))
+	if err != nil {
+		t.Error(err)
+	} else if result.Username != "café" {
+		t.Errorf("expect username '%s', but got '%s'", "café", result.Username)
+	}
+}
+
+func TestJSONBinderMaxDepth(t *testing.T) {
+	bind := func(b Binder, body string) error {
+		var v interface{}
+		req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		return b.Bind(&v, req)
+	}
+
+	nested := func(depth int) string {
+		return strings.Repeat(`{"a":[`, depth) + strings.Repeat(`]}`, depth)
+	}
+
+	binder := JSONBinder(WithMaxDepth(10))
+	if err := bind(binder, nested(5)); err != nil {
+		t.Errorf("expect no error, but got '%v'", err)
+	}
+	if err := bind(binder, `{"a":"[[[[[[[[[[[\"{{{{{{{{{{{"}`); err != nil {
+		t.Errorf("expect the brackets in the string to be ignored, but got '%v'", err)
+	}
+	if err := bind(binder, nested(6)); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if se, ok := err.(HTTPServerError); !ok || se.Code != http.StatusBadRequest {
+		t.Errorf("expect a 400 error, but got '%v'", err)
+	}
+
+	if err := bind(JSONBinder(), nested(DefaultJSONMaxDepth)); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+	if err := bind(JSONBinder(WithMaxDepth(0)), nested(DefaultJSONMaxDepth)); err != nil {
+		t.Errorf("expect no error, but got '%v'", err)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var (
+	decoderLock sync.RWMutex
+	decoders    = map[string]func(io.Reader) (io.Reader, error){
+		"gzip":    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"deflate": decodeDeflate,
+	}
+)
+
+// decodeDeflate decodes the body of the content encoding "deflate",
+// which is the zlib format by RFC 9110. But some servers send the raw
+// deflate data without the zlib header, so fall back to it.
+func decodeDeflate(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if h, err := br.Peek(2); err == nil && h[0]&0x0f == 8 &&
+		(uint16(h[0])<<8|uint16(h[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// RegisterContentDecoder registers the decoder of the response body
+// for the content encoding, such as "br", which will override
+// the existed decoder.
+//
+// The decoders of "gzip" and "deflate" have been registered by default.
+func RegisterContentDecoder(encoding string, decoder func(io.Reader) (io.Reader, error)) {
+	if encoding == "" {
+		panic("RegisterContentDecoder: the content encoding must not be empty")
+	} else if decoder == nil {
+		panic("RegisterContentDecoder: the content decoder must not be nil")
+	}
+
+	decoderLock.Lock()
+	decoders[strings.ToLower(encoding)] = decoder
+	decoderLock.Unlock()
+}
+
+// UnregisterContentDecoder unregisters the decoder of the content encoding.
+func UnregisterContentDecoder(encoding string) {
+	decoderLock.Lock()
+	delete(decoders, strings.ToLower(encoding))
+	decoderLock.Unlock()
+}
+
+// GetContentDecoder returns the registered decoder of the content encoding.
+//
+// Return nil if the decoder does not exist.
+func GetContentDecoder(encoding string) func(io.Reader) (io.Reader, error) {
+	decoderLock.RLock()
+	decoder := decoders[strings.ToLower(encoding)]
+	decoderLock.RUnlock()
+	return decoder
+}
+
+// DecodeResponseBody returns the reader of the response body, which is
+// decoded by the decoder of the response header "Content-Encoding".
+//
+// If the content encoding is empty or there is no the decoder of it,
+// return the raw response body.
+func DecodeResponseBody(resp *http.Response) (io.Reader, error) {
+	encoding := strings.TrimSpace(resp.Header.Get(HeaderContentEncoding))
+	if encoding == "" || resp.Body == nil || resp.Body == http.NoBody {
+		return resp.Body, nil
+	}
+
+	if decoder := GetContentDecoder(encoding); decoder != nil {
+		return decoder(resp.Body)
+	}
+	return resp.Body, nil
+}
+
+type multipartPart struct {
+	name     string
+	value    string
+	filename string
+	reader   io.Reader
+	consumed bool
+}
+
+// MultipartRequest is used to build the body of the multipart request.
+type MultipartRequest struct {
+	boundary string
+	parts    []multipartPart
+}
+
+// NewMultipartRequest returns a new multipart request builder.
+func NewMultipartRequest() *MultipartRequest {
+	return &MultipartRequest{boundary: multipart.NewWriter(nil).Boundary()}
+}
+
+// AddField adds the form field with the name and value.
+func (m *MultipartRequest) AddField(name, value string) *MultipartRequest {
+	m.parts = append(m.parts, multipartPart{name: name, value: value})
+	return m
+}
+
+// AddFile adds the form file with the field name and the file name,
+// the content of which is read from r.
+//
+// Notice: if r has implemented the interface io.Seeker, it will be seeked
+// to the start before building the body, so the body can be built
+// repeatedly, for example, retrying the request. Or, building the body
+// again returns an error instead of sending the empty file part.
+func (m *MultipartRequest) AddFile(name, filename string, r io.Reader) *MultipartRequest {
+	m.parts = append(m.parts, multipartPart{name: name, filename: filename, reader: r})
+	return m
+}
+
+// ContentType returns the Content-Type of the multipart body,
+// which is "multipart/form-data; boundary=<BOUNDARY>".
+func (m *MultipartRequest) ContentType() string {
+	return "multipart/form-data; boundary=" + m.boundary
+}
+
+// Body builds and returns the multipart body, which has the signature
+// of func() (io.Reader, error), so it can be used to build the body lazily.
+func (m *MultipartRequest) Body() (io.Reader, error) {
+	buf := bytes.NewBuffer(nil)
+	w := multipart.NewWriter(buf)
+	if err := w.SetBoundary(m.boundary); err != nil {
+		return nil, err
+	}
+
+	for i, part := range m.parts {
+		if part.reader == nil {
+			if err := w.WriteField(part.name, part.value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if seeker, ok := part.reader.(io.Seeker); ok {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+		} else if part.consumed {
+			return nil, fmt.Errorf("MultipartRequest: the file part '%s' cannot be rewound", part.name)
+		} else {
+			m.parts[i].consumed = true
+		}
+
+		fw, err := w.CreateFormFile(part.name, part.filename)
+		if err != nil {
+			return nil, err
+		} else if _, err = io.Copy(fw, part.reader); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// NewRequest returns a new http request with the multipart body,
+// which has set the header "Content-Type" and the method GetBody.
+func (m *MultipartRequest) NewRequest(ctx context.Context, method, url string) (*http.Request, error) {
+	body, err := m.Body()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(HeaderContentType, m.ContentType())
+	req.GetBody = func() (io.ReadCloser, error) {
+		body, err := m.Body()
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(body), nil
+	}
+
+	return req, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeResponseBody(t *testing.T) {
+	const data = "abcdefghijklmnopqrstuvwxyz"
+
+	gzipbuf := bytes.NewBuffer(nil)
+	gw := gzip.NewWriter(gzipbuf)
+	gw.Write([]byte(data))
+	gw.Close()
+
+	zlibbuf := bytes.NewBuffer(nil)
+	zw := zlib.NewWriter(zlibbuf)
+	zw.Write([]byte(data))
+	zw.Close()
+
+	flatebuf := bytes.NewBuffer(nil)
+	fw, _ := flate.NewWriter(flatebuf, flate.DefaultCompression)
+	fw.Write([]byte(data))
+	fw.Close()
+
+	RegisterContentDecoder("Upper", func(r io.Reader) (io.Reader, error) {
+		data, err := ioutil.ReadAll(r)
+		return strings.NewReader(strings.ToUpper(string(data))), err
+	})
+	defer UnregisterContentDecoder("Upper")
+
+	tests := []struct {
+		encoding string
+		body     []byte
+		expect   string
+	}{
+		{encoding: "", body: []byte(data), expect: data},
+		{encoding: "gzip", body: gzipbuf.Bytes(), expect: data},
+		{encoding: "deflate", body: zlibbuf.Bytes(), expect: data},
+		{encoding: "deflate", body: flatebuf.Bytes(), expect: data}, // raw deflate
+		{encoding: "upper", body: []byte(data), expect: strings.ToUpper(data)},
+		{encoding: "unknown", body: []byte(data), expect: data},
+	}
+
+	for _, test := range tests {
+		resp := &http.Response{
+			Header: http.Header{},
+			Body:   ioutil.NopCloser(bytes.NewReader(test.body)),
+		}
+		if test.encoding != "" {
+			resp.Header.Set(HeaderContentEncoding, test.encoding)
+		}
+
+		r, err := DecodeResponseBody(resp)
+		if err != nil {
+			t.Errorf("%s: %s", test.encoding, err)
+			continue
+		}
+
+		if body, err := ioutil.ReadAll(r); err != nil {
+			t.Errorf("%s: %s", test.encoding, err)
+		} else if string(body) != test.expect {
+			t.Errorf("%s: expect '%s', but got '%s'", test.encoding, test.expect, body)
+		}
+	}
+
+	UnregisterContentDecoder("UPPER")
+	if GetContentDecoder("upper") != nil {
+		t.Errorf("expect the decoder of 'upper' to be unregistered")
+	}
+}
+
+func TestMultipartRequest(t *testing.T) {
+	s := New()
+	s.Route("/upload").POST(func(c *Context) error {
+		f, fh, err := c.FormFile("file")
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return err
+		}
+
+		return c.Text(200, c.Form("name")+":"+fh.Filename+":"+string(data))
+	})
+
+	mr := NewMultipartRequest().
+		AddField("name", "abc").
+		AddFile("file", "test.txt", strings.NewReader("content"))
+
+	req, err := mr.NewRequest(context.Background(), http.MethodPost, "http://localhost/upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		// Use the shallow copy of the request to serve it, so that the body
+		// is re-read instead of using the multipart form cached in request.
+		r := req.WithContext(req.Context())
+		if i > 0 { // Replay the request body.
+			body, err := req.GetBody()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Body = body
+		}
+
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		if r.MultipartForm == nil {
+			t.Errorf("%d: expect the request body to be parsed", i)
+		}
+		if rec.Code != 200 {
+			t.Errorf("%d: expect status code %d, but got %d: %s", i, 200, rec.Code, rec.Body.String())
+		} else if body := rec.Body.String(); body != "abc:test.txt:content" {
+			t.Errorf("%d: expect body '%s', but got '%s'", i, "abc:test.txt:content", body)
+		}
+	}
+}
+
+func TestMultipartRequestUnseekable(t *testing.T) {
+	mr := NewMultipartRequest().AddFile("file", "test.txt",
+		ioutil.NopCloser(strings.NewReader("content")))
+
+	if _, err := mr.Body(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mr.Body(); err == nil {
+		t.Errorf("expect an error for the consumed file part, but got nil")
+	}
+}
@@ -27,6 +27,8 @@ const (
 	MIMETextPlain             = "text/plain"
 	MIMEApplicationXML        = "application/xml"
 	MIMEApplicationJSON       = "application/json"
+	MIMEApplicationNDJSON     = "application/x-ndjson"
+	MIMEApplicationMergePatch = "application/merge-patch+json"
 	MIMEApplicationJavaScript = "application/javascript"
 	MIMEApplicationForm       = "application/x-www-form-urlencoded"
 	MIMEApplicationProtobuf   = "application/protobuf"
@@ -128,5 +130,6 @@ const (
 	HeaderXXSSProtection          = "X-Xss-Protection"
 	HeaderXFrameOptions           = "X-Frame-Options"
 	HeaderContentSecurityPolicy   = "Content-Security-Policy"
+	HeaderReferrerPolicy          = "Referrer-Policy"
 	HeaderXCSRFToken              = "X-Csrf-Token"
 )
@@ -0,0 +1,141 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// MaxSubRequestDepth is the maximum depth of the nested sub-requests.
+const MaxSubRequestDepth = 8
+
+var (
+	// ErrNoShip is returned by Context.SubRequest when the context
+	// is not created by Ship.
+	ErrNoShip = errors.New("the context is not created by ship")
+
+	// ErrSubRequestDepth is returned by Context.SubRequest when the depth
+	// of the nested sub-requests exceeds MaxSubRequestDepth, for example,
+	// a route dispatches the sub-request to itself.
+	ErrSubRequestDepth = errors.New("the sub-request depth exceeds the limit")
+)
+
+type subRequestDepthKey struct{}
+
+func getSubRequestDepth(ctx context.Context) int {
+	depth, _ := ctx.Value(subRequestDepthKey{}).(int)
+	return depth
+}
+
+// SubResponse is the in-memory http.ResponseWriter used by the sub-request.
+type SubResponse struct {
+	Code      int
+	HeaderMap http.Header
+	Body      bytes.Buffer
+}
+
+// NewSubResponse returns a new SubResponse.
+func NewSubResponse() *SubResponse {
+	return &SubResponse{Code: http.StatusOK, HeaderMap: make(http.Header)}
+}
+
+// Header implements the interface http.ResponseWriter.
+func (r *SubResponse) Header() http.Header { return r.HeaderMap }
+
+// WriteHeader implements the interface http.ResponseWriter.
+func (r *SubResponse) WriteHeader(code int) { r.Code = code }
+
+// Write implements the interface http.ResponseWriter.
+func (r *SubResponse) Write(p []byte) (int, error) { return r.Body.Write(p) }
+
+// WriteString implements the interface io.StringWriter.
+func (r *SubResponse) WriteString(s string) (int, error) {
+	return r.Body.WriteString(s)
+}
+
+// SubRequest builds an in-memory request with the method, path and body,
+// and dispatches it through the whole routing stack of Ship, including
+// the middlewares and the error handler, which is useful to compose
+// a response from the other routes, such as ESI.
+//
+// The sub-request inherits the context, Host, RemoteAddr and the headers
+// of the parent request except Content-Length, and also Content-Type
+// if body is nil. The parent context is not modified.
+//
+// The depth of the nested sub-requests is tracked by the request context,
+// and ErrSubRequestDepth is returned if it exceeds MaxSubRequestDepth.
+//
+// The returned sub-context is acquired from the context pool of Ship,
+// and its response has been finished, whose writer is *SubResponse.
+// So the caller should release it by ReleaseSubRequest after using it.
+//
+// Example
+//
+//    sub, err := ctx.SubRequest(http.MethodGet, "/fragment?id=1", nil)
+//    if err != nil {
+//        return err
+//    }
+//    defer ctx.ReleaseSubRequest(sub)
+//
+//    resp := sub.ResponseWriter().(*ship.SubResponse)
+//    return ctx.Blob(resp.Code, resp.Header().Get(ship.HeaderContentType), resp.Body.Bytes())
+func (c *Context) SubRequest(method, path string, body io.Reader) (*Context, error) {
+	s := c.ship
+	if s == nil {
+		return nil, ErrNoShip
+	}
+
+	ctx := c.req.Context()
+	depth := getSubRequestDepth(ctx) + 1
+	if depth > MaxSubRequestDepth {
+		return nil, ErrSubRequestDepth
+	}
+
+	ctx = context.WithValue(ctx, subRequestDepthKey{}, depth)
+	req, err := NewRequestWithContext(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Host = c.req.Host
+	req.RemoteAddr = c.req.RemoteAddr
+	for key, values := range c.req.Header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	req.Header.Del(HeaderContentLength)
+	if body == nil {
+		req.Header.Del(HeaderContentType)
+	}
+	if len(s.cvalues) > 0 {
+		req = withContextValues(req, s.cvalues)
+	}
+
+	sub := s.AcquireContext(req, NewSubResponse())
+	s.serveContext(sub)
+	sub.res.Finish()
+	return sub, nil
+}
+
+// ReleaseSubRequest releases the sub-context returned by SubRequest
+// into the context pool.
+func (c *Context) ReleaseSubRequest(sub *Context) {
+	if sub != nil && c.ship != nil {
+		c.ship.ReleaseContext(sub)
+	}
+}
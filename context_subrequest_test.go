@@ -0,0 +1,118 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContextSubRequest(t *testing.T) {
+	s := Default()
+	s.Route("/fragment/:id").GET(func(c *Context) error {
+		return c.Text(200, "fragment "+c.Param("id")+" "+c.GetReqHeader("X-Token"))
+	})
+	s.Route("/echo").POST(func(c *Context) error {
+		data, err := ioutil.ReadAll(c.Body())
+		if err != nil {
+			return err
+		}
+		return c.Text(201, string(data))
+	})
+	s.Route("/page").GET(func(c *Context) error {
+		c.SetRespHeader("X-Parent", "yes")
+
+		sub1, err := c.SubRequest(http.MethodGet, "/fragment/123", nil)
+		if err != nil {
+			return err
+		}
+		defer c.ReleaseSubRequest(sub1)
+
+		sub2, err := c.SubRequest(http.MethodPost, "/echo", strings.NewReader("abc"))
+		if err != nil {
+			return err
+		}
+		defer c.ReleaseSubRequest(sub2)
+
+		sub3, err := c.SubRequest(http.MethodGet, "/missing", nil)
+		if err != nil {
+			return err
+		}
+		defer c.ReleaseSubRequest(sub3)
+
+		resp1 := sub1.ResponseWriter().(*SubResponse)
+		resp2 := sub2.ResponseWriter().(*SubResponse)
+		resp3 := sub3.ResponseWriter().(*SubResponse)
+		if resp1.Code != 200 || resp2.Code != 201 || resp3.Code != 404 {
+			t.Errorf("unexpected status codes: %d, %d, %d",
+				resp1.Code, resp2.Code, resp3.Code)
+		}
+		if resp1.Header().Get("X-Parent") != "" {
+			t.Errorf("the parent response header is leaked into the sub-response")
+		}
+		if c.Param("id") != "" {
+			t.Errorf("the parent context is corrupted: id=%s", c.Param("id"))
+		}
+
+		return c.Text(200, resp1.Body.String()+"|"+resp2.Body.String())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/page", nil)
+	req.Header.Set("X-Token", "token")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Errorf("expected status code '%d', but got '%d'", 200, rec.Code)
+	} else if body := rec.Body.String(); body != "fragment 123 token|abc" {
+		t.Errorf("unexpected response body '%s'", body)
+	} else if rec.Header().Get("X-Parent") != "yes" {
+		t.Errorf("the parent response header is missing")
+	}
+
+	c := NewContext(0, 0)
+	c.SetRequest(req)
+	if _, err := c.SubRequest(http.MethodGet, "/", nil); err != ErrNoShip {
+		t.Errorf("expected ErrNoShip, but got '%v'", err)
+	}
+}
+
+func TestContextSubRequestDepth(t *testing.T) {
+	var depth int
+	var lastErr error
+	s := Default()
+	s.Route("/loop").GET(func(c *Context) error {
+		depth++
+		sub, err := c.SubRequest(http.MethodGet, "/loop", nil)
+		if err != nil {
+			lastErr = err
+			return err
+		}
+		c.ReleaseSubRequest(sub)
+		return c.NoContent(204)
+	})
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/loop", nil))
+	if lastErr != ErrSubRequestDepth {
+		t.Errorf("expected ErrSubRequestDepth, but got '%v'", lastErr)
+	}
+	if expect := MaxSubRequestDepth + 1; depth != expect {
+		t.Errorf("expected depth '%d', but got '%d'", expect, depth)
+	}
+}
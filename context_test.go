@@ -15,10 +15,19 @@
 package ship
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func BenchmarkContext(b *testing.B) {
@@ -91,3 +100,969 @@ func TestContextAccept(t *testing.T) {
 		}
 	}
 }
+
+func TestContextETag(t *testing.T) {
+	router := New()
+	router.Route("/path").GET(func(c *Context) error {
+		if c.ETag("abc") {
+			return nil
+		}
+		return c.Text(200, "body")
+	})
+
+	tests := []struct {
+		inm  string
+		code int
+	}{
+		{inm: "", code: 200},
+		{inm: `"xyz"`, code: 200},
+		{inm: `"xyz", "abc"`, code: 304},
+		{inm: `W/"abc"`, code: 304},
+		{inm: `*`, code: 304},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/path", nil)
+		if test.inm != "" {
+			req.Header.Set(HeaderIfNoneMatch, test.inm)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != test.code {
+			t.Errorf("%s: expect status code %d, but got %d", test.inm, test.code, rec.Code)
+		} else if etag := rec.Header().Get(HeaderETag); etag != `"abc"` {
+			t.Errorf("expect etag '%s', but got '%s'", `"abc"`, etag)
+		}
+	}
+
+	// RFC 9110 Section 13.1.2: respond 412 instead of 304
+	// for the method other than GET and HEAD.
+	router.Route("/path").PUT(func(c *Context) error {
+		if c.ETag("abc") {
+			return nil
+		}
+		return c.NoContent(204)
+	})
+	req := httptest.NewRequest(http.MethodPut, "/path", nil)
+	req.Header.Set(HeaderIfNoneMatch, `"abc"`)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expect status code %d, but got %d", http.StatusPreconditionFailed, rec.Code)
+	}
+}
+
+func TestContextLastModified(t *testing.T) {
+	modtime := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	router := New()
+	router.Route("/path").GET(func(c *Context) error {
+		if c.LastModified(modtime) {
+			return nil
+		}
+		return c.Text(200, "body")
+	})
+
+	tests := []struct {
+		ims  time.Time
+		code int
+	}{
+		{ims: time.Time{}, code: 200},
+		{ims: modtime.Add(-time.Second), code: 200},
+		{ims: modtime, code: 304},
+		{ims: modtime.Add(time.Hour), code: 304},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/path", nil)
+		if !test.ims.IsZero() {
+			req.Header.Set(HeaderIfModifiedSince, test.ims.Format(http.TimeFormat))
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != test.code {
+			t.Errorf("%s: expect status code %d, but got %d", test.ims, test.code, rec.Code)
+		}
+	}
+}
+
+func TestContextPreferredLanguage(t *testing.T) {
+	tests := []struct {
+		accept    string
+		supported []string
+		expect    string
+	}{
+		{accept: "", supported: []string{"en", "zh-CN"}, expect: "en"},
+		{accept: "zh-CN, en;q=0.8", supported: []string{"en", "zh-CN"}, expect: "zh-CN"},
+		{accept: "fr, en-US;q=0.8", supported: []string{"zh", "en"}, expect: "en"},
+		{accept: "en", supported: []string{"zh", "en-GB"}, expect: "en-GB"},
+		{accept: "fr, *;q=0.5", supported: []string{"zh", "en"}, expect: "zh"},
+		{accept: "en", supported: nil, expect: ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderAcceptLanguage, test.accept)
+		c := NewContext(0, 0)
+		c.SetRequest(req)
+
+		if lang := c.PreferredLanguage(test.supported...); lang != test.expect {
+			t.Errorf("%s: expect '%s', but got '%s'", test.accept, test.expect, lang)
+		}
+	}
+}
+
+func TestContextURLQuery(t *testing.T) {
+	router := New()
+	router.Route("/items").Name("items").GET(OkHandler())
+	router.Route("/users/:id").Name("user").GET(OkHandler())
+
+	c := router.NewContext()
+	query := url.Values{"page": []string{"2"}}
+	if u := c.URLQuery("items", query); u != "/items?page=2" {
+		t.Errorf("expect '%s', but got '%s'", "/items?page=2", u)
+	}
+	if u := c.URLQuery("user", query, 123); u != "/users/123?page=2" {
+		t.Errorf("expect '%s', but got '%s'", "/users/123?page=2", u)
+	}
+	if u := c.URLQuery("user", nil, 123); u != "/users/123" {
+		t.Errorf("expect '%s', but got '%s'", "/users/123", u)
+	}
+	if u := c.URLQuery("none", query); u != "" {
+		t.Errorf("unexpected url '%s'", u)
+	}
+}
+
+func TestContextAbsoluteURL(t *testing.T) {
+	router := New()
+	router.Route("/users/:id").Name("user").GET(OkHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1/", nil)
+	req.Header.Set(HeaderXForwardedProto, "https")
+	c := router.AcquireContext(req, nil)
+	if u := c.AbsoluteURL("user", 123); u != "https://127.0.0.1/users/123" {
+		t.Errorf("expect '%s', but got '%s'", "https://127.0.0.1/users/123", u)
+	}
+	router.ReleaseContext(c)
+
+	router.BaseURL = "https://www.example.com/"
+	c = router.NewContext()
+	c.SetRequest(req)
+	if u := c.AbsoluteURL("user", 123); u != "https://www.example.com/users/123" {
+		t.Errorf("expect '%s', but got '%s'", "https://www.example.com/users/123", u)
+	}
+}
+
+func TestContextGetData(t *testing.T) {
+	c := NewContext(0, 0)
+	c.Data["string"] = "abc"
+	c.Data["int"] = 123
+	c.Data["bool"] = true
+
+	if v := c.GetString("string"); v != "abc" {
+		t.Errorf("expect '%s', but got '%s'", "abc", v)
+	} else if v := c.GetString("int"); v != "" {
+		t.Errorf("expect '', but got '%s'", v)
+	}
+
+	if v := c.GetInt("int"); v != 123 {
+		t.Errorf("expect %d, but got %d", 123, v)
+	} else if v := c.GetInt("string"); v != 0 {
+		t.Errorf("expect 0, but got %d", v)
+	}
+
+	if v := c.GetBool("bool"); !v {
+		t.Errorf("expect true, but got false")
+	} else if v := c.GetBool("missing"); v {
+		t.Errorf("expect false, but got true")
+	}
+
+	if v, ok := c.GetData("int"); !ok || v != 123 {
+		t.Errorf("expect %d, but got %v", 123, v)
+	} else if _, ok := c.GetData("missing"); ok {
+		t.Errorf("unexpect the key 'missing' to exist")
+	}
+
+	if v := c.MustGet("string"); v != "abc" {
+		t.Errorf("expect '%s', but got '%v'", "abc", v)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expect a panic, but got nil")
+			}
+		}()
+		c.MustGet("missing")
+	}()
+}
+
+func TestContextBindQueryStrict(t *testing.T) {
+	type V struct {
+		PageSize int `query:"page_size"`
+	}
+
+	router := New()
+	router.Route("/path").GET(func(c *Context) error {
+		var v V
+		if err := c.BindQueryStrict(&v); err != nil {
+			return err
+		}
+		return c.Text(200, strconv.FormatInt(int64(v.PageSize), 10))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/path?page_size=10", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("StatusCode: expect %d, got %d", http.StatusOK, rec.Code)
+	} else if body := rec.Body.String(); body != "10" {
+		t.Errorf("expect '%s', got '%s'", "10", body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/path?pageSize=10&page_size=10", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("StatusCode: expect %d, got %d", http.StatusBadRequest, rec.Code)
+	} else if body := rec.Body.String(); body != "unknown keys: pageSize" {
+		t.Errorf("expect '%s', got '%s'", "unknown keys: pageSize", body)
+	}
+}
+
+func TestContextJSONStream(t *testing.T) {
+	s := New()
+	s.Route("/").GET(func(c *Context) error {
+		w, err := c.JSONStream(200)
+		if err != nil {
+			return err
+		}
+
+		w.FlushEvery = 2
+		for i := 0; i < 3; i++ {
+			if err = w.Write(map[string]int{"id": i}); err != nil {
+				return err
+			}
+		}
+		return w.Close()
+	})
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	expect := `[{"id":0},{"id":1},{"id":2}]`
+	if rec.Code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, rec.Code)
+	} else if body := rec.Body.String(); body != expect {
+		t.Errorf("expect body '%s', but got '%s'", expect, body)
+	} else if ct := rec.Header().Get(HeaderContentType); ct != MIMEApplicationJSONCharsetUTF8 {
+		t.Errorf("expect Content-Type '%s', but got '%s'", MIMEApplicationJSONCharsetUTF8, ct)
+	} else if !rec.Flushed {
+		t.Errorf("expect the response to be flushed")
+	}
+
+	s.Route("/empty").GET(func(c *Context) error {
+		w, err := c.JSONStream(200)
+		if err != nil {
+			return err
+		}
+		return w.Close()
+	})
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/empty", nil))
+	if body := rec.Body.String(); body != "[]" {
+		t.Errorf("expect body '%s', but got '%s'", "[]", body)
+	}
+}
+
+func TestContextQueryParser(t *testing.T) {
+	s := New()
+	s.QueryParser = ParseQueryWithSemicolon
+	s.Route("/").GET(func(c *Context) error {
+		return c.Text(200, c.Query("a")+c.Queries().Get("b"))
+	})
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?a=1;b=2", nil))
+	if body := rec.Body.String(); body != "12" {
+		t.Errorf("expect body '%s', but got '%s'", "12", body)
+	}
+}
+
+func TestContextBindBodyAndQuery(t *testing.T) {
+	type V struct {
+		Name  string `query:"name" json:"name"`
+		Page  int    `query:"page" json:"page"`
+		Value string `query:"value" json:"value"`
+	}
+
+	s := Default()
+	s.Route("/").PATCH(func(c *Context) error {
+		var v V
+		bodyWins := c.Query("wins") == "body"
+		if err := c.BindBodyAndQuery(&v, bodyWins); err != nil {
+			return err
+		}
+		return c.Text(200, fmt.Sprintf("%s:%d:%s", v.Name, v.Page, v.Value))
+	})
+
+	for wins, expect := range map[string]string{"body": "body:2:v", "query": "query:2:v"} {
+		body := strings.NewReader(`{"name":"body","value":"v"}`)
+		req := httptest.NewRequest(http.MethodPatch, "/?wins="+wins+"&name=query&page=2", body)
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+
+		if rec.Code != 200 {
+			t.Errorf("%s: expect status code %d, but got %d", wins, 200, rec.Code)
+		} else if body := rec.Body.String(); body != expect {
+			t.Errorf("%s: expect body '%s', but got '%s'", wins, expect, body)
+		}
+	}
+}
+
+func TestContextBindHeaders(t *testing.T) {
+	type Headers struct {
+		APIKey   string   `header:"x-api-key,required"`
+		TenantID int      `header:"X-Tenant-ID"`
+		Tags     []string `header:"X-Tag"`
+		Ignore   string   `header:"-"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Key", "key")
+	req.Header.Set("X-Tenant-Id", "123")
+	req.Header.Add("X-Tag", "a")
+	req.Header.Add("X-Tag", "b")
+
+	var h Headers
+	c := New().AcquireContext(req, httptest.NewRecorder())
+	if err := c.BindHeaders(&h); err != nil {
+		t.Fatal(err)
+	}
+
+	if h.APIKey != "key" {
+		t.Errorf("expect APIKey '%s', but got '%s'", "key", h.APIKey)
+	}
+	if h.TenantID != 123 {
+		t.Errorf("expect TenantID %d, but got %d", 123, h.TenantID)
+	}
+	if len(h.Tags) != 2 || h.Tags[0] != "a" || h.Tags[1] != "b" {
+		t.Errorf("expect Tags %v, but got %v", []string{"a", "b"}, h.Tags)
+	}
+
+	req.Header.Del("X-Api-Key")
+	if err := c.BindHeaders(&Headers{}); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if se, ok := err.(HTTPServerError); !ok || se.Code != http.StatusBadRequest {
+		t.Errorf("expect a 400 error, but got '%v'", err)
+	}
+}
+
+type testCookieTheme struct{ Name string }
+
+func (t *testCookieTheme) UnmarshalBind(param string) error {
+	t.Name = strings.ToUpper(param)
+	return nil
+}
+
+func TestContextBindCookies(t *testing.T) {
+	type Cookies struct {
+		Session string          `cookie:"session,required"`
+		UserID  int             `cookie:"uid"`
+		Theme   testCookieTheme `cookie:"theme"`
+		Lang    *string         `cookie:"lang,required"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	req.AddCookie(&http.Cookie{Name: "uid", Value: "123"})
+	req.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+	var v Cookies
+	c := New().AcquireContext(req, httptest.NewRecorder())
+	if err := c.BindCookies(&v); err != nil {
+		t.Fatal(err)
+	} else if v.Session != "abc" {
+		t.Errorf("expect Session '%s', but got '%s'", "abc", v.Session)
+	} else if v.UserID != 123 {
+		t.Errorf("expect UserID %d, but got %d", 123, v.UserID)
+	} else if v.Theme.Name != "DARK" {
+		t.Errorf("expect Theme '%s', but got '%s'", "DARK", v.Theme.Name)
+	} else if v.Lang != nil {
+		t.Errorf("unexpected Lang '%s'", *v.Lang)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	c = New().AcquireContext(req, httptest.NewRecorder())
+	err := c.BindCookies(&v)
+	if se, ok := err.(HTTPServerError); !ok || se.Code != http.StatusBadRequest {
+		t.Errorf("expect a 400 error, but got %v", err)
+	} else if se.Err.Error() != "missing keys: session" {
+		t.Errorf("expect error '%s', but got '%s'", "missing keys: session", se.Err.Error())
+	}
+}
+
+type testNonFlusherWriter struct{ http.ResponseWriter }
+
+func TestContextChunkedWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := New().AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	c.SetRespHeader(HeaderContentLength, "100")
+
+	w, err := c.ChunkedWriter(200, MIMETextPlain)
+	if err != nil {
+		t.Fatal(err)
+	} else if !rec.Flushed {
+		t.Errorf("the response header is not flushed")
+	}
+
+	io.WriteString(w, "abc")
+	io.WriteString(w, "def")
+	w.Close()
+
+	if body := rec.Body.String(); body != "abcdef" {
+		t.Errorf("expect body '%s', but got '%s'", "abcdef", body)
+	} else if v := rec.Header().Get(HeaderContentLength); v != "" {
+		t.Errorf("unexpected Content-Length '%s'", v)
+	} else if ct := rec.Header().Get(HeaderContentType); ct != MIMETextPlain {
+		t.Errorf("expect Content-Type '%s', but got '%s'", MIMETextPlain, ct)
+	}
+
+	rec = httptest.NewRecorder()
+	c = New().AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil),
+		testNonFlusherWriter{rec})
+	if _, err := c.ChunkedWriter(200, MIMETextPlain); err != ErrNotFlusher {
+		t.Errorf("expect error ErrNotFlusher, but got %v", err)
+	}
+}
+
+func TestContextRawBody(t *testing.T) {
+	type V struct {
+		Name string `json:"name"`
+	}
+
+	s := Default()
+	s.Route("/").POST(func(c *Context) error {
+		body, err := c.RawBody()
+		if err != nil {
+			return err
+		} else if string(body) != `{"name":"abc"}` {
+			t.Errorf("unexpected raw body '%s'", body)
+		}
+
+		if body, _ = c.RawBody(); string(body) != `{"name":"abc"}` {
+			t.Errorf("unexpected cached raw body '%s'", body)
+		}
+
+		var v V
+		if err = c.Bind(&v); err != nil {
+			return err
+		}
+		return c.Text(200, v.Name)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"abc"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, rec.Code)
+	} else if body := rec.Body.String(); body != "abc" {
+		t.Errorf("expect body '%s', but got '%s'", "abc", body)
+	}
+}
+
+func TestContextNoCacheAndCacheFor(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := New().AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	c.NoCache()
+
+	header := c.RespHeader()
+	if v := header.Get(HeaderCacheControl); v != "no-store, no-cache, must-revalidate" {
+		t.Errorf("unexpected Cache-Control '%s'", v)
+	} else if v := header.Get(HeaderPragma); v != "no-cache" {
+		t.Errorf("unexpected Pragma '%s'", v)
+	} else if v := header.Get(HeaderExpires); v != "0" {
+		t.Errorf("unexpected Expires '%s'", v)
+	}
+
+	c.CacheFor(time.Hour)
+	if v := header.Get(HeaderCacheControl); v != "public, max-age=3600" {
+		t.Errorf("unexpected Cache-Control '%s'", v)
+	}
+}
+
+func TestContextPreload(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := New().AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	c.RespHeader().Set(HeaderLink, `<https://example.com>; rel="sunset"`)
+	c.Preload(PreloadResource{URL: "/app.js", As: "script"})
+	c.Preload(PreloadResource{URL: "/app.css", As: "style"}, PreloadResource{URL: "/data"})
+
+	expects := []string{
+		`<https://example.com>; rel="sunset"`,
+		"</app.js>; rel=preload; as=script",
+		"</app.css>; rel=preload; as=style",
+		"</data>; rel=preload",
+	}
+	if links := c.RespHeader()[HeaderLink]; !reflect.DeepEqual(links, expects) {
+		t.Errorf("expect links %v, but got %v", expects, links)
+	}
+}
+
+func TestContextBindMergePatch(t *testing.T) {
+	type Address struct {
+		City   string `json:"city,omitempty"`
+		Street string `json:"street,omitempty"`
+	}
+	type User struct {
+		Name    string   `json:"name"`
+		Age     int      `json:"age"`
+		Tags    []string `json:"tags"`
+		Address *Address `json:"address,omitempty"`
+	}
+
+	original := User{
+		Name:    "abc",
+		Age:     18,
+		Tags:    []string{"a", "b"},
+		Address: &Address{City: "c1", Street: "s1"},
+	}
+
+	body := `{"age":null,"tags":["c"],"address":{"street":null}}`
+	req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, MIMEApplicationMergePatch)
+	c := New().AcquireContext(req, httptest.NewRecorder())
+
+	var patch map[string]interface{}
+	if err := c.BindMergePatch(&original, &patch); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := User{Name: "abc", Tags: []string{"c"}, Address: &Address{City: "c1"}}
+	if !reflect.DeepEqual(original, expect) {
+		t.Errorf("expect %+v, but got %+v", expect, original)
+	} else if _, ok := patch["age"]; !ok || len(patch) != 3 {
+		t.Errorf("unexpected patch document %v", patch)
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c = New().AcquireContext(req, httptest.NewRecorder())
+	if err := c.BindMergePatch(&original, nil); err != ErrUnsupportedMediaType {
+		t.Errorf("expect ErrUnsupportedMediaType, but got %v", err)
+	}
+}
+
+func TestContextBindMergePatchIgnoredFields(t *testing.T) {
+	type Inner struct {
+		C      string `json:"c"`
+		Secret string `json:"-"`
+	}
+	type Data struct {
+		A      string `json:"a"`
+		Secret string `json:"-"`
+		B      int    `json:"b"`
+		Inner  *Inner `json:"inner"`
+		hidden string
+	}
+
+	original := Data{
+		A:      "x",
+		Secret: "keep",
+		B:      1,
+		Inner:  &Inner{C: "c", Secret: "inner"},
+		hidden: "hidden",
+	}
+
+	body := `{"b":2,"inner":{"c":"d"}}`
+	req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, MIMEApplicationMergePatch)
+	c := New().AcquireContext(req, httptest.NewRecorder())
+	if err := c.BindMergePatch(&original, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := Data{
+		A:      "x",
+		Secret: "keep",
+		B:      2,
+		Inner:  &Inner{C: "d", Secret: "inner"},
+		hidden: "hidden",
+	}
+	if !reflect.DeepEqual(original, expect) {
+		t.Errorf("expect %+v, but got %+v", expect, original)
+	}
+}
+
+func TestContextParamsRetained(t *testing.T) {
+	var params []map[string]string
+	s := New()
+	s.Route("/users/:id").GET(func(c *Context) error {
+		params = append(params, c.Params())
+		return nil
+	})
+
+	for _, id := range []string{"1", "2"} {
+		s.ServeHTTP(httptest.NewRecorder(),
+			httptest.NewRequest(http.MethodGet, "/users/"+id, nil))
+	}
+
+	if len(params) != 2 || params[0]["id"] != "1" || params[1]["id"] != "2" {
+		t.Errorf("the retained params are changed: %v", params)
+	}
+}
+
+func TestContextParamsCopy(t *testing.T) {
+	s := New()
+	s.Route("/users/:id/:name").GET(func(c *Context) error { return nil })
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1/abc", nil)
+	c := s.AcquireContext(req, httptest.NewRecorder())
+	c.Execute()
+	params := c.ParamsCopy()
+
+	c.Reset()
+	c.SetRequest(httptest.NewRequest(http.MethodGet, "/users/2/xyz", nil))
+	c.Execute()
+
+	if len(params) != 2 || params["id"] != "1" || params["name"] != "abc" {
+		t.Errorf("the stored params are changed: %v", params)
+	}
+	if p := c.ParamsCopy(); p["id"] != "2" || p["name"] != "xyz" {
+		t.Errorf("unexpected params: %v", p)
+	}
+	s.ReleaseContext(c)
+}
+
+func TestContextQueriesReused(t *testing.T) {
+	s := New()
+	s.Route("/").GET(func(c *Context) error {
+		return c.Text(200, "%v", c.Queries())
+	})
+
+	for query, expect := range map[string]string{
+		"/?a=1&b=2&b=3": "map[a:[1] b:[2 3]]",
+		"/?c=4":         "map[c:[4]]",
+		"/":             "map[]",
+		"/?d=a+b&e=%zz": "map[d:[a b]]",
+		"/?f=1;g=2&h=3": "map[h:[3]]",
+	} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, query, nil))
+		if body := rec.Body.String(); body != expect {
+			t.Errorf("%s: expect '%s', but got '%s'", query, expect, body)
+		}
+	}
+}
+
+func TestContextCopyBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("abcdef"))
+	c := New().AcquireContext(req, httptest.NewRecorder())
+
+	buf := bytes.NewBuffer(nil)
+	if n, err := c.CopyBody(buf); err != nil {
+		t.Fatal(err)
+	} else if n != 6 || buf.String() != "abcdef" {
+		t.Errorf("expect '%s', but got %d '%s'", "abcdef", n, buf.String())
+	}
+}
+
+func TestContextMaxBodySize(t *testing.T) {
+	type reader struct{ io.Reader } // Hide the content length.
+
+	s := New()
+	s.MaxBodySize = 4
+	for _, body := range []io.Reader{strings.NewReader("abcdef"), reader{strings.NewReader("abcdef")}} {
+		req := httptest.NewRequest(http.MethodPost, "/", body)
+		c := s.AcquireContext(req, httptest.NewRecorder())
+		if _, err := c.RawBody(); err != ErrStatusRequestEntityTooLarge {
+			t.Errorf("%T: expect ErrStatusRequestEntityTooLarge, but got '%v'", body, err)
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	req := httptest.NewRequest(http.MethodPost, "/", reader{strings.NewReader("abcdef")})
+	c := s.AcquireContext(req, httptest.NewRecorder())
+	if n, err := c.CopyBody(buf); err != ErrStatusRequestEntityTooLarge {
+		t.Errorf("expect ErrStatusRequestEntityTooLarge, but got '%v'", err)
+	} else if n != 4 || buf.String() != "abcd" {
+		t.Errorf("expect to copy '%s', but got '%s'", "abcd", buf.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", reader{strings.NewReader("abcd")})
+	c = s.AcquireContext(req, httptest.NewRecorder())
+	if body, err := c.RawBody(); err != nil {
+		t.Error(err)
+	} else if string(body) != "abcd" {
+		t.Errorf("expect body '%s', but got '%s'", "abcd", body)
+	}
+}
+
+func TestContextSetCookieWithDefaults(t *testing.T) {
+	s := New()
+	s.CookieDefaults = &http.Cookie{
+		Path:     "/",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	rec := httptest.NewRecorder()
+	c := s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	cookie := &http.Cookie{Name: "a", Value: "1", Path: "/api", SameSite: http.SameSiteStrictMode}
+	c.SetCookie(cookie)
+	c.SetCookie(&http.Cookie{Name: "b", Value: "2"})
+
+	if cookie.Secure || cookie.HttpOnly {
+		t.Errorf("the original cookie is modified")
+	}
+
+	expects := []string{
+		"a=1; Path=/api; HttpOnly; Secure; SameSite=Strict",
+		"b=2; Path=/; HttpOnly; Secure; SameSite=Lax",
+	}
+	if cookies := rec.Header()[HeaderSetCookie]; !reflect.DeepEqual(cookies, expects) {
+		t.Errorf("expect cookies %v, but got %v", expects, cookies)
+	}
+}
+
+func TestContextIfMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		etags  []string
+	}{
+		{header: "", etags: nil},
+		{header: "*", etags: []string{"*"}},
+		{header: `"xyzzy"`, etags: []string{`"xyzzy"`}},
+		{header: `"a,b", W/"c" ,bad, "d"`, etags: []string{`"a,b"`, `W/"c"`, `"d"`}},
+		{header: `W/"unterminated`, etags: nil},
+	}
+
+	s := New()
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPut, "/", nil)
+		req.Header.Set(HeaderIfMatch, test.header)
+		req.Header.Set(HeaderIfNoneMatch, test.header)
+		c := s.AcquireContext(req, httptest.NewRecorder())
+
+		if etags := c.IfMatch(); !reflect.DeepEqual(etags, test.etags) {
+			t.Errorf("If-Match '%s': expect %q, but got %q", test.header, test.etags, etags)
+		}
+		if etags := c.IfNoneMatch(); !reflect.DeepEqual(etags, test.etags) {
+			t.Errorf("If-None-Match '%s': expect %q, but got %q", test.header, test.etags, etags)
+		}
+		s.ReleaseContext(c)
+	}
+
+	rec := httptest.NewRecorder()
+	c := s.AcquireContext(httptest.NewRequest(http.MethodPut, "/", nil), rec)
+	if err := c.PreconditionFailed(); err != nil {
+		t.Error(err)
+	} else if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expect status code %d, but got %d", http.StatusPreconditionFailed, rec.Code)
+	}
+}
+
+func TestContextRespond(t *testing.T) {
+	errTest := errors.New("test")
+	tests := []struct {
+		args []interface{}
+		code int
+		ct   string
+		body string
+		err  error
+	}{
+		{args: nil, code: 200},
+		{args: []interface{}{nil}, code: 200},
+		{args: []interface{}{201}, code: 201},
+		{args: []interface{}{"text"}, code: 200, ct: MIMETextPlainCharsetUTF8, body: "text"},
+		{args: []interface{}{[]byte("bytes")}, code: 200, ct: MIMEOctetStream, body: "bytes"},
+		{args: []interface{}{map[string]int{"a": 1}}, code: 200, ct: MIMEApplicationJSONCharsetUTF8, body: `{"a":1}` + "\n"},
+		{args: []interface{}{errTest}, err: errTest},
+		{args: []interface{}{400, errTest}, err: HTTPServerError{Code: 400, Err: errTest}},
+		{args: []interface{}{202, nil}, code: 202},
+		{args: []interface{}{202, "text"}, code: 202, ct: MIMETextPlainCharsetUTF8, body: "text"},
+		{args: []interface{}{202, []int{1}}, code: 202, ct: MIMEApplicationJSONCharsetUTF8, body: "[1]\n"},
+	}
+
+	s := New()
+	for i, test := range tests {
+		rec := httptest.NewRecorder()
+		c := s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+		err := c.Respond(test.args...)
+		s.ReleaseContext(c)
+
+		if test.err != nil {
+			if err != test.err {
+				t.Errorf("%d: expect error '%v', but got '%v'", i, test.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		} else if rec.Code != test.code {
+			t.Errorf("%d: expect status code %d, but got %d", i, test.code, rec.Code)
+		} else if ct := rec.Header().Get(HeaderContentType); ct != test.ct {
+			t.Errorf("%d: expect content type '%s', but got '%s'", i, test.ct, ct)
+		} else if body := rec.Body.String(); body != test.body {
+			t.Errorf("%d: expect body '%s', but got '%s'", i, test.body, body)
+		}
+	}
+
+	c := s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	if err := c.Respond("a", "b"); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
+
+type testWriterRenderer struct{}
+
+func (testWriterRenderer) Render(w http.ResponseWriter, name string, code int, data interface{}) error {
+	return nil
+}
+
+func (testWriterRenderer) RenderTo(w io.Writer, name string, data interface{}) error {
+	_, err := fmt.Fprintf(w, "%s:%v", name, data)
+	return err
+}
+
+func TestContextRenderTo(t *testing.T) {
+	mr := NewMuxRenderer()
+	mr.Add("tmpl", testWriterRenderer{})
+	mr.Add("html", RendererFunc(func(http.ResponseWriter, string, int, interface{}) error {
+		return nil
+	}))
+
+	s := New()
+	s.Renderer = mr
+	c := s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	buf := bytes.NewBuffer(nil)
+	if err := c.RenderTo(buf, "email.tmpl", "data"); err != nil {
+		t.Error(err)
+	} else if s := buf.String(); s != "email.tmpl:data" {
+		t.Errorf("expect '%s', but got '%s'", "email.tmpl:data", s)
+	}
+
+	if err := c.RenderTo(buf, "index.html", nil); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+	if err := c.RenderTo(buf, "index.none", nil); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
+
+func TestMuxRendererRender(t *testing.T) {
+	mr := NewMuxRenderer()
+	mr.Add("html", testWriterRenderer{})
+	mr.Add("tmpl", testWriterRenderer{})
+
+	s := New()
+	s.Renderer = mr
+
+	rec := httptest.NewRecorder()
+	c := s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if err := c.Render("index.html", 201, "data"); err != nil {
+		t.Error(err)
+	} else if rec.Code != 201 {
+		t.Errorf("expect status code %d, but got %d", 201, rec.Code)
+	} else if body := rec.Body.String(); body != "index.html:data" {
+		t.Errorf("expect body '%s', but got '%s'", "index.html:data", body)
+	} else if ct := rec.Header().Get(HeaderContentType); !strings.EqualFold(ct, MIMETextHTMLCharsetUTF8) {
+		t.Errorf("expect Content-Type '%s', but got '%s'", MIMETextHTMLCharsetUTF8, ct)
+	}
+
+	// The MIME type of ".tmpl" is unknown, so use the Render method.
+	rec = httptest.NewRecorder()
+	c = s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if err := c.Render("email.tmpl", 200, "data"); err != nil {
+		t.Error(err)
+	} else if body := rec.Body.String(); body != "" {
+		t.Errorf("expect the empty body, but got '%s'", body)
+	}
+}
+
+func TestContextRenderWithContentType(t *testing.T) {
+	htmlRenderer := RendererFunc(func(w http.ResponseWriter, name string, code int, data interface{}) error {
+		w.Header().Set(HeaderContentType, MIMETextHTMLCharsetUTF8)
+		w.WriteHeader(code)
+		_, err := fmt.Fprintf(w, "<p>%v</p>", data)
+		return err
+	})
+
+	for _, renderer := range []Renderer{htmlRenderer, testWriterRenderer{}} {
+		s := New()
+		s.Renderer = renderer
+
+		rec := httptest.NewRecorder()
+		c := s.AcquireContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+		if err := c.RenderWithContentType("frag", 201, MIMETextPlainCharsetUTF8, "data"); err != nil {
+			t.Error(err)
+		} else if rec.Code != 201 {
+			t.Errorf("%T: expect status code %d, but got %d", renderer, 201, rec.Code)
+		} else if ct := rec.Header().Get(HeaderContentType); ct != MIMETextPlainCharsetUTF8 {
+			t.Errorf("%T: expect content type '%s', but got '%s'", renderer, MIMETextPlainCharsetUTF8, ct)
+		} else if rec.Body.Len() == 0 {
+			t.Errorf("%T: expect the body, but got nothing", renderer)
+		}
+		s.ReleaseContext(c)
+	}
+}
+
+func TestContextMediaType(t *testing.T) {
+	c := NewContext(0, 0)
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	c.SetRequest(req)
+
+	if mt, params, err := c.MediaType(); err != nil || mt != "" || params != nil {
+		t.Errorf("unexpected result: mediatype=%s, params=%v, err=%v", mt, params, err)
+	}
+
+	req.Header.Set(HeaderContentType, `Multipart/Form-Data; boundary="abc"; charset=utf-8`)
+	mt, params, err := c.MediaType()
+	if err != nil {
+		t.Error(err)
+	} else if mt != MIMEMultipartForm {
+		t.Errorf("expect media type '%s', but got '%s'", MIMEMultipartForm, mt)
+	} else if expect := map[string]string{"boundary": "abc", "charset": "utf-8"}; !reflect.DeepEqual(params, expect) {
+		t.Errorf("expect params %v, but got %v", expect, params)
+	}
+
+	req.Header.Set(HeaderContentType, "text/plain; charset")
+	if _, _, err = c.MediaType(); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if se, ok := err.(HTTPServerError); !ok || se.Code != http.StatusBadRequest {
+		t.Errorf("expect a 400 error, but got '%v'", err)
+	}
+}
+
+func TestContextCharset(t *testing.T) {
+	c := NewContext(0, 0)
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	c.SetRequest(req)
+
+	tests := []struct {
+		ct      string
+		charset string
+	}{
+		{"", ""},
+		{"application/json", ""},
+		{"application/json; charset=UTF-8", "utf-8"},
+		{`application/json; charset="utf-8"`, "utf-8"},
+		{"application/json; charset=utf-8; foo=bar", "utf-8"},
+		{"application/json; foo=bar; Charset=GBK", "gbk"},
+		{"application/json; charset", ""},
+	}
+
+	for _, test := range tests {
+		req.Header.Set(HeaderContentType, test.ct)
+		if charset := c.Charset(); charset != test.charset {
+			t.Errorf("%s: expect charset '%s', but got '%s'", test.ct, test.charset, charset)
+		}
+	}
+}
@@ -15,9 +15,14 @@
 package ship
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"reflect"
+	"strings"
 )
 
 // Some non-HTTP Errors
@@ -26,6 +31,7 @@ var (
 	ErrInvalidRedirectCode = errors.New("invalid redirect status code")
 	ErrSessionNotExist     = errors.New("session does not exist")
 	ErrInvalidSession      = errors.New("invalid session")
+	ErrNotFlusher          = errors.New("response writer does not support flushing")
 )
 
 // Some HTTP error.
@@ -40,7 +46,9 @@ var (
 	ErrStatusConflict                = NewHTTPServerError(http.StatusConflict)
 	ErrStatusGone                    = NewHTTPServerError(http.StatusGone)
 	ErrStatusRequestEntityTooLarge   = NewHTTPServerError(http.StatusRequestEntityTooLarge)
+	ErrStatusRequestURITooLong       = NewHTTPServerError(http.StatusRequestURITooLong)
 	ErrUnsupportedMediaType          = NewHTTPServerError(http.StatusUnsupportedMediaType)
+	ErrUnprocessableEntity           = NewHTTPServerError(http.StatusUnprocessableEntity)
 	ErrTooManyRequests               = NewHTTPServerError(http.StatusTooManyRequests)
 	ErrInternalServerError           = NewHTTPServerError(http.StatusInternalServerError)
 	ErrStatusNotImplemented          = NewHTTPServerError(http.StatusNotImplemented)
@@ -54,6 +62,75 @@ var (
 // skip and return it back to the outer middleware to handle.
 var ErrSkip = errors.New("skip")
 
+// IsClientDisconnect reports whether the error, or any error wrapped by it,
+// is caused by the disconnected client, such as context.Canceled,
+// context.DeadlineExceeded or the closed network connection.
+//
+// Notice: it only inspects the error, which may be also returned by
+// the server side, such as the timeout of the database query. So check
+// whether the context of the request is done as well if necessary.
+func IsClientDisconnect(err error) bool {
+	for err != nil {
+		switch err {
+		case context.Canceled, context.DeadlineExceeded:
+			return true
+		}
+
+		if isNetClosedError(err) {
+			return true
+		}
+
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = u.Unwrap()
+	}
+	return false
+}
+
+// errorMapping is the table to map the domain errors to HTTPServerError,
+// which is set by Ship.MapError and Ship.MapErrorType.
+type errorMapping struct {
+	errs  []errorStatus
+	types []errorType
+}
+
+type errorStatus struct {
+	target error
+	status int
+}
+
+type errorType struct {
+	typ reflect.Type
+	fn  func(error) HTTPServerError
+}
+
+var errorInterfaceType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Map maps the error, or any error wrapped by it, to HTTPServerError
+// with the semantics of errors.Is and errors.As.
+// The sentinel errors set by MapError are tried before the error types.
+func (m *errorMapping) Map(err error) (HTTPServerError, bool) {
+	if m == nil || (len(m.errs) == 0 && len(m.types) == 0) {
+		return HTTPServerError{}, false
+	}
+
+	for _, es := range m.errs {
+		if isError(err, es.target) {
+			return HTTPServerError{Code: es.status, Err: err}, true
+		}
+	}
+
+	for _, et := range m.types {
+		if e, ok := asErrorType(err, et.typ); ok {
+			return et.fn(e), true
+		}
+	}
+
+	return HTTPServerError{}, false
+}
+
 // RouteError represents a route error when adding a route.
 type RouteError struct {
 	Err error
@@ -69,6 +146,20 @@ func (re RouteError) Error() string {
 		re.Err, re.Name, re.Path, re.Method)
 }
 
+// Unwrap unwraps the inner error.
+func (re RouteError) Unwrap() error { return re.Err }
+
+// RouteErrors represents a set of the route errors.
+type RouteErrors []RouteError
+
+func (es RouteErrors) Error() string {
+	ss := make([]string, len(es))
+	for i, e := range es {
+		ss[i] = e.Error()
+	}
+	return strings.Join(ss, "; ")
+}
+
 // HTTPServerError represents a server error with HTTP Status Code.
 type HTTPServerError struct {
 	Code int
@@ -103,6 +194,10 @@ func (e HTTPServerError) Newf(msg string, args ...interface{}) HTTPServerError {
 	return e.New(fmt.Errorf(msg, args...))
 }
 
+// MaxHTTPClientErrorBodySize is the maximum size of the response body
+// captured by NewHTTPClientErrorFromResponse.
+var MaxHTTPClientErrorBodySize = 4096
+
 // HTTPClientError represents an response error from the http client.
 type HTTPClientError struct {
 	Code   int    `json:"code" xml:"code"`
@@ -110,6 +205,13 @@ type HTTPClientError struct {
 	URL    string `json:"url" xml:"url"`
 	Data   string `json:"data" xml:"data"`
 	Err    error  `json:"err" xml:"err"`
+
+	// CT is the Content-Type of the response.
+	CT string `json:"ct,omitempty" xml:"ct,omitempty"`
+
+	// Truncated reports whether Data is truncated
+	// because the response body is too large.
+	Truncated bool `json:"truncated,omitempty" xml:"truncated,omitempty"`
 }
 
 // NewHTTPClientError returns a new HTTPClientError.
@@ -123,6 +225,47 @@ func NewHTTPClientError(method, url string, code int, err error,
 	return HTTPClientError{Method: method, URL: url, Code: code, Data: d, Err: err}
 }
 
+// NewHTTPClientErrorFromResponse returns a new HTTPClientError from
+// the response, which captures at most MaxHTTPClientErrorBodySize bytes
+// of the response body decoded by DecodeResponseBody as Data.
+//
+// Notice: the response body is not closed.
+func NewHTTPClientErrorFromResponse(resp *http.Response, err error) HTTPClientError {
+	e := HTTPClientError{
+		Code: resp.StatusCode,
+		CT:   resp.Header.Get(HeaderContentType),
+		Err:  err,
+	}
+
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL.String()
+	}
+
+	if resp.Body != nil {
+		var data []byte
+		maxsize := MaxHTTPClientErrorBodySize
+		body, rerr := DecodeResponseBody(resp)
+		if rerr == nil {
+			data, rerr = ioutil.ReadAll(io.LimitReader(body, int64(maxsize)+1))
+		}
+
+		if len(data) > maxsize {
+			data, e.Truncated = data[:maxsize], true
+		}
+
+		e.Data = string(data)
+		if e.Err == nil && rerr != nil {
+			e.Err = rerr
+		}
+	}
+
+	return e
+}
+
+// Body returns the captured response body.
+func (e HTTPClientError) Body() []byte { return []byte(e.Data) }
+
 func (e HTTPClientError) Unwrap() error  { return e.Err }
 func (e HTTPClientError) String() string { return e.Error() }
 func (e HTTPClientError) Error() string {
@@ -132,7 +275,9 @@ func (e HTTPClientError) Error() string {
 	}
 
 	var data string
-	if e.Data != "" {
+	if e.Truncated {
+		data = fmt.Sprintf(", data=%s...(truncated)", e.Data)
+	} else if e.Data != "" {
 		data = fmt.Sprintf(", data=%s", e.Data)
 	}
 
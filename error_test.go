@@ -0,0 +1,96 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorUnwrap(t *testing.T) {
+	s := Default()
+
+	var bindErr error
+	s.Route("/").POST(func(c *Context) error {
+		var v struct{ Name string }
+		if err := c.Bind(&v); err != nil {
+			bindErr = ErrBadRequest.New(err)
+			return bindErr
+		}
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"Name": x}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expect status code %d, but got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	var serr HTTPServerError
+	if !errors.As(bindErr, &serr) {
+		t.Errorf("expect an HTTPServerError, but got %T", bindErr)
+	}
+
+	var jerr *json.SyntaxError
+	if !errors.As(bindErr, &jerr) {
+		t.Errorf("expect a json error, but got %T: %v", serr.Err, bindErr)
+	}
+
+	rerr := RouteError{Route: Route{Path: "/"}, Err: ErrInvalidSession}
+	if !errors.Is(rerr, ErrInvalidSession) {
+		t.Errorf("expect the error '%v', but got '%v'", ErrInvalidSession, rerr)
+	}
+
+	cerr := NewHTTPClientError(http.MethodGet, "/", 500, ErrInvalidSession)
+	if !errors.Is(cerr, ErrInvalidSession) {
+		t.Errorf("expect the error '%v', but got '%v'", ErrInvalidSession, cerr)
+	}
+}
+
+func TestNewHTTPClientErrorFromResponse(t *testing.T) {
+	defer func(size int) { MaxHTTPClientErrorBodySize = size }(MaxHTTPClientErrorBodySize)
+	MaxHTTPClientErrorBodySize = 4
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	rec.WriteHeader(http.StatusBadRequest)
+	rec.WriteString(`{"error":"abc"}`)
+
+	resp := rec.Result()
+	resp.Request = httptest.NewRequest(http.MethodGet, "http://localhost/path", nil)
+	err := NewHTTPClientErrorFromResponse(resp, nil)
+
+	if err.Code != http.StatusBadRequest {
+		t.Errorf("expect status code %d, but got %d", http.StatusBadRequest, err.Code)
+	} else if err.CT != MIMEApplicationJSON {
+		t.Errorf("expect Content-Type '%s', but got '%s'", MIMEApplicationJSON, err.CT)
+	} else if body := string(err.Body()); body != `{"er` {
+		t.Errorf("expect body '%s', but got '%s'", `{"er`, body)
+	} else if !err.Truncated {
+		t.Errorf("expect the body to be truncated")
+	}
+
+	expect := `method=GET, url=http://localhost/path, code=400, data={"er...(truncated)`
+	if s := err.Error(); s != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, s)
+	}
+}
@@ -18,8 +18,10 @@ package ship
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
+	"reflect"
 )
 
 // NewRequestWithContext is the compatibility of http.NewRequestWithContext.
@@ -27,3 +29,16 @@ func NewRequestWithContext(ctx context.Context, method, url string,
 	body io.Reader) (*http.Request, error) {
 	return http.NewRequestWithContext(ctx, method, url, body)
 }
+
+// isError is the compatibility of errors.Is.
+func isError(err, target error) bool { return errors.Is(err, target) }
+
+// asErrorType is the compatibility of errors.As, but uses the type typ
+// as the target and returns the matched error.
+func asErrorType(err error, typ reflect.Type) (error, bool) {
+	target := reflect.New(typ)
+	if errors.As(err, target.Interface()) {
+		return target.Elem().Interface().(error), true
+	}
+	return nil, false
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"reflect"
 )
 
 // NewRequestWithContext is the compatibility of http.NewRequestWithContext.
@@ -31,3 +32,42 @@ func NewRequestWithContext(ctx context.Context, method, url string,
 	}
 	return req, err
 }
+
+// isError is the compatibility of errors.Is.
+func isError(err, target error) bool {
+	comparable := reflect.TypeOf(target).Comparable()
+	for ; err != nil; err = unwrapError(err) {
+		if comparable && err == target {
+			return true
+		}
+		if x, ok := err.(interface{ Is(error) bool }); ok && x.Is(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// asErrorType is the compatibility of errors.As, but uses the type typ
+// as the target and returns the matched error.
+func asErrorType(err error, typ reflect.Type) (error, bool) {
+	for ; err != nil; err = unwrapError(err) {
+		if t := reflect.TypeOf(err); t == typ ||
+			(typ.Kind() == reflect.Interface && t.Implements(typ)) {
+			return err, true
+		}
+
+		if x, ok := err.(interface{ As(interface{}) bool }); ok {
+			if target := reflect.New(typ); x.As(target.Interface()) {
+				return target.Elem().Interface().(error), true
+			}
+		}
+	}
+	return nil, false
+}
+
+func unwrapError(err error) error {
+	if u, ok := err.(interface{ Unwrap() error }); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ package ship
 
 import (
 	"net/http"
+	"runtime"
 	"strings"
 )
 
@@ -65,6 +66,12 @@ func FromHTTPHandlerFunc(h http.HandlerFunc) Handler {
 	return FromHTTPHandler(h)
 }
 
+// FromHTTPHandlerFuncE converts the http handler function returning an error
+// to Handler, which returns the error of the function.
+func FromHTTPHandlerFuncE(h func(http.ResponseWriter, *http.Request) error) Handler {
+	return func(ctx *Context) error { return h(ctx.res, ctx.req) }
+}
+
 // NothingHandler returns a Handler doing nothing.
 func NothingHandler() Handler { return func(*Context) error { return nil } }
 
@@ -80,6 +87,38 @@ func NotFoundHandler() Handler {
 	}
 }
 
+const notFoundHTML = `<!DOCTYPE html>
+<html>
+<head><title>404 Not Found</title></head>
+<body><h1>404 Not Found</h1></body>
+</html>
+`
+
+// contentNegotiatingNotFound returns a NotFound handler, which responds
+// {"error":"not found"} as JSON for the client accepting JSON, the html page
+// for the client preferring HTML, or the plain text "Not Found" for others.
+//
+// If the error page for 404 is registered by Ship.SetErrorPage, return
+// ErrNotFound for the client preferring HTML to render the error page
+// by HandleError.
+func contentNegotiatingNotFound() Handler {
+	return func(c *Context) error {
+		if _, ok := c.errorPages[http.StatusNotFound]; ok && c.acceptHTML() {
+			return ErrNotFound
+		}
+
+		switch c.Negotiate(MIMETextPlain, MIMEApplicationJSON, MIMETextHTML) {
+		case MIMEApplicationJSON:
+			return c.BlobText(http.StatusNotFound, MIMEApplicationJSONCharsetUTF8,
+				`{"error":"not found"}`)
+		case MIMETextHTML:
+			return c.HTML(http.StatusNotFound, notFoundHTML)
+		default:
+			return c.Text(http.StatusNotFound, "Not Found")
+		}
+	}
+}
+
 // MethodNotAllowedHandler returns a MethodNotAllowed handler.
 func MethodNotAllowedHandler(allowedMethods []string) Handler {
 	return func(c *Context) error {
@@ -87,3 +126,21 @@ func MethodNotAllowedHandler(allowedMethods []string) Handler {
 		return c.NoContent(http.StatusMethodNotAllowed)
 	}
 }
+
+// VersionInfo is the build and version information of the program.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// VersionHandler returns a Handler to send the version information as JSON.
+//
+// If GoVersion is empty, it is set to runtime.Version() by default.
+func VersionHandler(info VersionInfo) Handler {
+	if info.GoVersion == "" {
+		info.GoVersion = runtime.Version()
+	}
+	return func(c *Context) error { return c.JSON(http.StatusOK, info) }
+}
@@ -17,6 +17,8 @@ package ship
 import (
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -43,3 +45,71 @@ func TestHTTPHandler(t *testing.T) {
 		t.Errorf("expect status code '%d', but got '%d'", 404, rec.Code)
 	}
 }
+
+func TestVersionHandler(t *testing.T) {
+	s := New()
+	s.Route("/version").GET(VersionHandler(VersionInfo{Version: "1.0.0", Commit: "abc"}))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	expect := `{"version":"1.0.0","commit":"abc","buildTime":"","goVersion":"` +
+		runtime.Version() + `"}`
+	if body := strings.TrimSpace(rec.Body.String()); body != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, body)
+	}
+}
+
+func TestFromHTTPHandlerFuncE(t *testing.T) {
+	s := New()
+	s.Route("/ok").GET(FromHTTPHandlerFuncE(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	}))
+	s.Route("/err").GET(FromHTTPHandlerFuncE(func(w http.ResponseWriter, r *http.Request) error {
+		return ErrBadRequest
+	}))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expect status code %d, but got %d", http.StatusCreated, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/err", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expect status code %d, but got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestContentNegotiatingNotFound(t *testing.T) {
+	s := New()
+	tests := []struct {
+		accept string
+		ct     string
+		body   string
+	}{
+		{accept: "", ct: MIMETextPlainCharsetUTF8, body: "Not Found"},
+		{accept: "*/*", ct: MIMETextPlainCharsetUTF8, body: "Not Found"},
+		{accept: "application/json", ct: MIMEApplicationJSONCharsetUTF8, body: `{"error":"not found"}`},
+		{accept: "text/html,*/*;q=0.8", ct: MIMETextHTMLCharsetUTF8, body: notFoundHTML},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+		if test.accept != "" {
+			req.Header.Set(HeaderAccept, test.accept)
+		}
+
+		s.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expect status code %d, but got %d", test.accept, http.StatusNotFound, rec.Code)
+		} else if ct := rec.Header().Get(HeaderContentType); ct != test.ct {
+			t.Errorf("%s: expect content type '%s', but got '%s'", test.accept, test.ct, ct)
+		} else if body := rec.Body.String(); body != test.body {
+			t.Errorf("%s: expect body '%s', but got '%s'", test.accept, test.body, body)
+		}
+	}
+}
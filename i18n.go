@@ -0,0 +1,146 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LocaleDataKey is the key of Context.Data to store the selected locale,
+// which is set by the middleware Locale and used by Context.T.
+const LocaleDataKey = "locale"
+
+// MessageBundle is used to look up the localized messages.
+type MessageBundle interface {
+	// Languages returns the supported languages, the first of which is
+	// the default language.
+	Languages() []string
+
+	// Message returns the message of the key in the language lang,
+	// and reports whether it exists.
+	Message(lang, key string) (msg string, ok bool)
+}
+
+// NewMapMessageBundle returns a new MessageBundle based on the map,
+// the key of which is the language and the value of which is the messages.
+//
+// defaultLang is the default language, which should be in messages.
+func NewMapMessageBundle(defaultLang string, messages map[string]map[string]string) MessageBundle {
+	langs := make([]string, 0, len(messages))
+	for lang := range messages {
+		if lang != defaultLang {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	if defaultLang != "" {
+		langs = append([]string{defaultLang}, langs...)
+	}
+	return mapMessageBundle{langs: langs, msgs: messages}
+}
+
+type mapMessageBundle struct {
+	langs []string
+	msgs  map[string]map[string]string
+}
+
+func (b mapMessageBundle) Languages() []string { return b.langs }
+func (b mapMessageBundle) Message(lang, key string) (msg string, ok bool) {
+	if msgs, exist := b.msgs[lang]; exist {
+		msg, ok = msgs[key]
+	}
+	return
+}
+
+// LoadJSONMessageBundle loads the message files named "<lang>.json" from dir,
+// each of which is a JSON object mapping the message key to the message,
+// and returns a MessageBundle based on the map.
+//
+// For other formats, such as TOML, you can decode them into the map
+// by yourself and use NewMapMessageBundle instead.
+func LoadJSONMessageBundle(dir, defaultLang string) (MessageBundle, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make(map[string]map[string]string, len(files))
+	for _, fi := range files {
+		if fi.IsDir() || filepath.Ext(fi.Name()) != ".json" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var msgs map[string]string
+		if err = json.Unmarshal(data, &msgs); err != nil {
+			return nil, fmt.Errorf("fail to decode the message file '%s': %s",
+				fi.Name(), err)
+		}
+		messages[strings.TrimSuffix(fi.Name(), ".json")] = msgs
+	}
+
+	if _, ok := messages[defaultLang]; !ok && defaultLang != "" {
+		return nil, fmt.Errorf("missing the message file of the default language '%s'",
+			defaultLang)
+	}
+
+	return NewMapMessageBundle(defaultLang, messages), nil
+}
+
+// Locale returns the selected locale of the request, which is read from
+// Data by the key LocaleDataKey. If not set, it is selected by
+// PreferredLanguage from the supported languages of MessageBundle.
+func (c *Context) Locale() string {
+	if locale, ok := c.Data[LocaleDataKey].(string); ok && locale != "" {
+		return locale
+	} else if c.MessageBundle != nil {
+		return c.PreferredLanguage(c.MessageBundle.Languages()...)
+	}
+	return ""
+}
+
+// T translates the message of the key by MessageBundle in the locale
+// returned by Locale, which is formatted by fmt.Sprintf with args if given.
+//
+// If the message does not exist in the locale, try the default language.
+// If still not exist, or MessageBundle is nil, use the key as the message.
+func (c *Context) T(key string, args ...interface{}) string {
+	msg := key
+	if c.MessageBundle != nil {
+		var ok bool
+		if msg, ok = c.MessageBundle.Message(c.Locale(), key); !ok {
+			langs := c.MessageBundle.Languages()
+			if len(langs) == 0 {
+				msg = key
+			} else if msg, ok = c.MessageBundle.Message(langs[0], key); !ok {
+				msg = key
+			}
+		}
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadJSONMessageBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ship_i18n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "en.json"), []byte(`{"hello":"Hello"}`), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "zh.json"), []byte(`{"hello":"你好"}`), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte(`ignored`), 0600)
+
+	bundle, err := LoadJSONMessageBundle(dir, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if langs := bundle.Languages(); len(langs) != 2 || langs[0] != "en" || langs[1] != "zh" {
+		t.Errorf("unexpected languages %v", langs)
+	}
+	if msg, ok := bundle.Message("zh", "hello"); !ok || msg != "你好" {
+		t.Errorf("expect message '%s', but got '%s'", "你好", msg)
+	}
+	if _, ok := bundle.Message("zh", "bye"); ok {
+		t.Errorf("unexpected the message 'bye'")
+	}
+
+	if _, err = LoadJSONMessageBundle(dir, "fr"); err == nil {
+		t.Errorf("expect an error for the missing default language")
+	}
+}
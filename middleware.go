@@ -14,7 +14,13 @@
 
 package ship
 
-import "net/http"
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
 
 // Middleware represents a middleware.
 type Middleware func(Handler) Handler
@@ -57,3 +63,52 @@ func (h mwHandler) HandleHTTP(w http.ResponseWriter, r *http.Request) error {
 	}
 	return Handler(h)(c)
 }
+
+// middlewareName returns the name of the function of the middleware,
+// such as "middleware.Logger", which removes the package path
+// and the suffixes of the anonymous functions.
+func middlewareName(m Middleware) string {
+	f := runtime.FuncForPC(reflect.ValueOf(m).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+
+	name := f.Name()
+	if index := strings.LastIndexByte(name, '/'); index > -1 {
+		pkgpath := name[:index]
+		name = name[index+1:]
+
+		// Use the real package name instead of the major version suffix,
+		// such as "github.com/xgfone/ship/v5.NewMiddleware".
+		if isMajorVersion(name) {
+			name = name[strings.IndexByte(name, '.'):]
+			name = pkgpath[strings.LastIndexByte(pkgpath, '/')+1:] + name
+		}
+	}
+
+	for {
+		index := strings.LastIndex(name, ".func")
+		if index < 0 {
+			break
+		} else if _, err := strconv.Atoi(name[index+5:]); err != nil {
+			break
+		}
+		name = name[:index]
+	}
+	return name
+}
+
+// isMajorVersion reports whether the function name starts with the major
+// version suffix of the module path, such as "v5.NewMiddleware".
+func isMajorVersion(name string) bool {
+	index := strings.IndexByte(name, '.')
+	if index < 2 || name[0] != 'v' {
+		return false
+	}
+	for i := 1; i < index; i++ {
+		if name[i] < '0' || name[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,312 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xgfone/ship/v5"
+)
+
+// CachedResponse is the response cached by the Cache middleware.
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+
+	// Vary is the names of the request headers in the response header "Vary".
+	//
+	// If not empty, it is only a placeholder cached by the request uri,
+	// and the responses varying by the request headers are cached
+	// by the keys containing the values of these request headers.
+	Vary []string
+}
+
+// CacheStore is used to store the cached responses.
+type CacheStore interface {
+	// Get returns the cached response by the key.
+	//
+	// Return (nil, false) if not exist or expired.
+	Get(key string) (*CachedResponse, bool)
+
+	// Set caches the response with the key for the ttl duration.
+	Set(key string, resp *CachedResponse, ttl time.Duration)
+}
+
+// DefaultCacheMaxEntries is the default maximum number of the responses
+// cached by the memory cache store.
+const DefaultCacheMaxEntries = 1024
+
+// cacheSweepInterval is the minimum interval to remove all the expired
+// responses from the memory cache store when setting a response.
+const cacheSweepInterval = time.Minute
+
+type memoryCacheItem struct {
+	key     string
+	resp    *CachedResponse
+	expires time.Time
+}
+
+type memoryCacheStore struct {
+	lock  sync.Mutex
+	max   int
+	items map[string]*list.Element
+	lru   *list.List
+	swept time.Time
+}
+
+// NewMemoryCacheStore returns a new CacheStore based on memory,
+// which caches maxEntries responses at most, DefaultCacheMaxEntries
+// by default, and evicts the least recently used one when it is full.
+//
+// The expired response is removed when getting it, and all the expired
+// responses are removed periodically when setting a response.
+func NewMemoryCacheStore(maxEntries ...int) CacheStore {
+	max := DefaultCacheMaxEntries
+	if len(maxEntries) > 0 && maxEntries[0] > 0 {
+		max = maxEntries[0]
+	}
+
+	return &memoryCacheStore{
+		max:   max,
+		lru:   list.New(),
+		items: make(map[string]*list.Element, 64),
+		swept: time.Now(),
+	}
+}
+
+func (s *memoryCacheStore) Get(key string) (resp *CachedResponse, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := elem.Value.(*memoryCacheItem)
+	if time.Now().After(item.expires) {
+		s.remove(elem)
+		return nil, false
+	}
+
+	s.lru.MoveToFront(elem)
+	return item.resp, true
+}
+
+func (s *memoryCacheStore) Set(key string, resp *CachedResponse, ttl time.Duration) {
+	now := time.Now()
+	item := &memoryCacheItem{key: key, resp: resp, expires: now.Add(ttl)}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if now.Sub(s.swept) >= cacheSweepInterval {
+		s.swept = now
+		for elem := s.lru.Back(); elem != nil; {
+			prev := elem.Prev()
+			if now.After(elem.Value.(*memoryCacheItem).expires) {
+				s.remove(elem)
+			}
+			elem = prev
+		}
+	}
+
+	if elem, ok := s.items[key]; ok {
+		elem.Value = item
+		s.lru.MoveToFront(elem)
+		return
+	}
+
+	s.items[key] = s.lru.PushFront(item)
+	for s.lru.Len() > s.max {
+		s.remove(s.lru.Back())
+	}
+}
+
+func (s *memoryCacheStore) remove(elem *list.Element) {
+	s.lru.Remove(elem)
+	delete(s.items, elem.Value.(*memoryCacheItem).key)
+}
+
+// Cache returns a middleware to cache the responses of the GET requests
+// into store for the ttl duration.
+//
+// On hit, it responds with the cached status code, headers and body directly.
+// On miss, it captures the response of the handler and caches it only if
+// the handler returns no error, the status code is 2xx, the response
+// header "Cache-Control" contains neither "no-store" nor "private",
+// and there is no response header "Set-Cookie", so that the response
+// for a certain client is not replayed to others.
+//
+// The response header "Vary" is honored, that's, the responses are cached
+// respectively by the values of the request headers listed in "Vary".
+// And the response with "Vary: *" is not cached.
+//
+// Notice: the response is not cached if Ship.ResponseFilter is set,
+// because the filtered response is sent only when finishing the request.
+//
+// If keyFunc is nil, use the request uri as the cache key by default.
+func Cache(store CacheStore, ttl time.Duration, keyFunc func(*ship.Context) string) Middleware {
+	if store == nil {
+		panic("Cache: the cache store must not be nil")
+	} else if ttl <= 0 {
+		panic("Cache: the ttl must be greater than 0")
+	}
+
+	if keyFunc == nil {
+		keyFunc = func(c *ship.Context) string { return c.RequestURI() }
+	}
+
+	return func(next ship.Handler) ship.Handler {
+		return func(c *ship.Context) (err error) {
+			if c.Method() != http.MethodGet {
+				return next(c)
+			}
+
+			key := keyFunc(c)
+			resp, ok := store.Get(key)
+			if ok && len(resp.Vary) > 0 {
+				resp, ok = store.Get(varyCacheKey(c, key, resp.Vary))
+			}
+			if ok {
+				header := c.RespHeader()
+				for k, vs := range resp.Header {
+					header[k] = append([]string(nil), vs...)
+				}
+				c.WriteHeader(resp.Status)
+				_, err = c.Write(resp.Body)
+				return
+			}
+
+			res := c.Response()
+			w := &cacheResponseWriter{ResponseWriter: res.ResponseWriter}
+			res.SetWriter(w)
+			err = next(c)
+			res.SetWriter(w.ResponseWriter)
+
+			// If the response is buffered by Ship.ResponseFilter, it is sent
+			// only when finishing the request, so it is not cached.
+			header := res.Header()
+			if err != nil || !res.Wrote || !w.wrote || res.Status < 200 ||
+				res.Status > 299 || !isCacheableHeader(header) {
+				return
+			}
+
+			vary := parseVary(header)
+			for _, name := range vary {
+				if name == "*" {
+					return
+				}
+			}
+
+			resp = &CachedResponse{
+				Status: res.Status,
+				Header: cloneHeader(header),
+				Body:   w.body.Bytes(),
+			}
+
+			if len(vary) == 0 {
+				store.Set(key, resp, ttl)
+			} else {
+				store.Set(key, &CachedResponse{Vary: vary}, ttl)
+				store.Set(varyCacheKey(c, key, vary), resp, ttl)
+			}
+
+			return
+		}
+	}
+}
+
+func isCacheableHeader(header http.Header) bool {
+	if len(header[ship.HeaderSetCookie]) > 0 {
+		return false
+	}
+
+	for _, value := range header[ship.HeaderCacheControl] {
+		for _, directive := range strings.Split(value, ",") {
+			if index := strings.IndexByte(directive, '='); index > -1 {
+				directive = directive[:index]
+			}
+
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "no-store", "private":
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func parseVary(header http.Header) (names []string) {
+	for _, value := range header[ship.HeaderVary] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return
+}
+
+func varyCacheKey(c *ship.Context, key string, vary []string) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range vary {
+		b.WriteByte(0)
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strings.Join(c.ReqHeader()[name], ","))
+	}
+	return b.String()
+}
+
+type cacheResponseWriter struct {
+	http.ResponseWriter
+	body  bytes.Buffer
+	wrote bool
+}
+
+func (w *cacheResponseWriter) WriteHeader(code int) {
+	w.wrote = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheResponseWriter) Write(p []byte) (n int, err error) {
+	w.wrote = true
+	n, err = w.ResponseWriter.Write(p)
+	w.body.Write(p[:n])
+	return
+}
+
+func (w *cacheResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func cloneHeader(h http.Header) http.Header {
+	nh := make(http.Header, len(h))
+	for k, vs := range h {
+		nh[k] = append([]string(nil), vs...)
+	}
+	return nh
+}
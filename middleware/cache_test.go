@@ -0,0 +1,175 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xgfone/ship/v5"
+)
+
+func TestCache(t *testing.T) {
+	var calls int
+	s := ship.New()
+	s.Use(Cache(NewMemoryCacheStore(), time.Minute, nil))
+	s.Route("/data").GET(func(c *ship.Context) error {
+		calls++
+		c.SetRespHeader("X-Data", "1")
+		return c.Text(200, "data")
+	})
+	s.Route("/nostore").GET(func(c *ship.Context) error {
+		calls++
+		c.SetRespHeader(ship.HeaderCacheControl, "no-store")
+		return c.Text(200, "nostore")
+	})
+	s.Route("/error").GET(func(c *ship.Context) error {
+		calls++
+		return ship.ErrBadRequest
+	})
+	s.Route("/private").GET(func(c *ship.Context) error {
+		calls++
+		c.SetRespHeader(ship.HeaderCacheControl, "max-age=60, Private")
+		return c.Text(200, "private")
+	})
+	s.Route("/cookie").GET(func(c *ship.Context) error {
+		calls++
+		c.SetCookie(&http.Cookie{Name: "session", Value: "secret"})
+		return c.Text(200, "cookie")
+	})
+	s.Route("/varyall").GET(func(c *ship.Context) error {
+		calls++
+		c.SetRespHeader(ship.HeaderVary, "*")
+		return c.Text(200, "varyall")
+	})
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/data", nil))
+		if rec.Code != 200 {
+			t.Errorf("expect status code %d, but got %d", 200, rec.Code)
+		} else if body := rec.Body.String(); body != "data" {
+			t.Errorf("expect body '%s', but got '%s'", "data", body)
+		} else if v := rec.Header().Get("X-Data"); v != "1" {
+			t.Errorf("expect header X-Data '%s', but got '%s'", "1", v)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expect %d calls, but got %d", 1, calls)
+	}
+
+	for _, path := range []string{"/nostore", "/error", "/private", "/cookie", "/varyall"} {
+		calls = 0
+		for i := 0; i < 2; i++ {
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		}
+		if calls != 2 {
+			t.Errorf("%s: expect %d calls, but got %d", path, 2, calls)
+		}
+	}
+}
+
+func TestCacheVary(t *testing.T) {
+	var calls int
+	s := ship.New()
+	s.Use(Cache(NewMemoryCacheStore(), time.Minute, nil))
+	s.Route("/vary").GET(func(c *ship.Context) error {
+		calls++
+		c.SetRespHeader(ship.HeaderVary, "Accept-Language")
+		return c.Text(200, c.GetReqHeader(ship.HeaderAcceptLanguage))
+	})
+
+	for _, lang := range []string{"en", "zh", "en", "zh"} {
+		req := httptest.NewRequest(http.MethodGet, "/vary", nil)
+		req.Header.Set(ship.HeaderAcceptLanguage, lang)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if body := rec.Body.String(); body != lang {
+			t.Errorf("expect body '%s', but got '%s'", lang, body)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("expect %d calls, but got %d", 2, calls)
+	}
+}
+
+func TestCacheResponseFilter(t *testing.T) {
+	var calls int
+	s := ship.New()
+	s.ResponseFilter = bytes.ToUpper
+	s.Use(Cache(NewMemoryCacheStore(), time.Minute, nil))
+	s.Route("/filter").GET(func(c *ship.Context) error {
+		calls++
+		return c.Text(200, "hello")
+	})
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/filter", nil))
+		if rec.Code != 200 {
+			t.Errorf("%d: expect status code %d, but got %d", i, 200, rec.Code)
+		} else if body := rec.Body.String(); body != "HELLO" {
+			t.Errorf("%d: expect body '%s', but got '%s'", i, "HELLO", body)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("expect %d calls, but got %d", 2, calls)
+	}
+}
+
+func TestMemoryCacheStoreLRU(t *testing.T) {
+	store := NewMemoryCacheStore(2)
+	store.Set("a", &CachedResponse{Status: 200}, time.Minute)
+	store.Set("b", &CachedResponse{Status: 200}, time.Minute)
+	store.Get("a")
+	store.Set("c", &CachedResponse{Status: 200}, time.Minute)
+
+	if _, ok := store.Get("b"); ok {
+		t.Errorf("expect the least recently used response to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := store.Get(key); !ok {
+			t.Errorf("expect the cached response '%s', but got nothing", key)
+		}
+	}
+
+	ms := store.(*memoryCacheStore)
+	ms.Set("d", &CachedResponse{Status: 200}, time.Millisecond)
+	time.Sleep(time.Millisecond * 5)
+	ms.swept = time.Now().Add(-cacheSweepInterval)
+	ms.Set("e", &CachedResponse{Status: 200}, time.Minute)
+	if _, ok := ms.items["d"]; ok {
+		t.Errorf("expect the expired response to be swept when setting")
+	}
+}
+
+func TestMemoryCacheStoreExpire(t *testing.T) {
+	store := NewMemoryCacheStore()
+	store.Set("key", &CachedResponse{Status: 200}, time.Millisecond)
+	if _, ok := store.Get("key"); !ok {
+		t.Errorf("expect the cached response, but got nothing")
+	}
+
+	time.Sleep(time.Millisecond * 5)
+	if _, ok := store.Get("key"); ok {
+		t.Errorf("unexpected the expired cached response")
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import "github.com/xgfone/ship/v5"
+
+// FlagProvider is used to check whether a feature flag is enabled.
+type FlagProvider interface {
+	IsEnabled(flag string, c *ship.Context) bool
+}
+
+// FlagProviderFunc is the function version of FlagProvider.
+type FlagProviderFunc func(flag string, c *ship.Context) bool
+
+// IsEnabled implements the interface FlagProvider.
+func (f FlagProviderFunc) IsEnabled(flag string, c *ship.Context) bool {
+	return f(flag, c)
+}
+
+// FeatureGate returns a Middleware to check whether the feature flag is enabled
+// by the provider when handling each request. If enabled, continue to handle
+// the request by the next handler. Or, handle it by onDisabled.
+//
+// If onDisabled is nil, it returns the error ship.ErrNotFound by default.
+func FeatureGate(flag string, provider FlagProvider, onDisabled ship.Handler) Middleware {
+	if provider == nil {
+		panic("FeatureGate: the flag provider must not be nil")
+	}
+
+	if onDisabled == nil {
+		onDisabled = func(*ship.Context) error { return ship.ErrNotFound }
+	}
+
+	return func(next ship.Handler) ship.Handler {
+		return func(c *ship.Context) error {
+			if provider.IsEnabled(flag, c) {
+				return next(c)
+			}
+			return onDisabled(c)
+		}
+	}
+}
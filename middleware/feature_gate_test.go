@@ -0,0 +1,64 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xgfone/ship/v5"
+)
+
+func TestFeatureGate(t *testing.T) {
+	flags := map[string]bool{"new": true}
+	provider := FlagProviderFunc(func(flag string, c *ship.Context) bool {
+		return flags[flag] || c.GetReqHeader("X-Beta") == "1"
+	})
+
+	unavailable := func(c *ship.Context) error {
+		return c.Text(http.StatusServiceUnavailable, "disabled")
+	}
+
+	s := ship.New()
+	handler := func(c *ship.Context) error { return c.Text(200, "ok") }
+	s.Route("/new").Use(FeatureGate("new", provider, nil)).GET(handler)
+	s.Route("/beta").Use(FeatureGate("beta", provider, unavailable)).GET(handler)
+	s.Route("/old").Use(FeatureGate("old", provider, nil)).GET(handler)
+
+	tests := []struct {
+		path string
+		beta bool
+		code int
+	}{
+		{path: "/new", code: 200},
+		{path: "/beta", code: 503},
+		{path: "/beta", beta: true, code: 200},
+		{path: "/old", code: 404},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.beta {
+			req.Header.Set("X-Beta", "1")
+		}
+
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != test.code {
+			t.Errorf("%s: expect status code %d, but got %d", test.path, test.code, rec.Code)
+		}
+	}
+}
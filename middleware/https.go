@@ -0,0 +1,106 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/xgfone/ship/v5"
+)
+
+// HTTPSConfig is used to configure the HTTPSRedirect and HTTPSOnly middlewares.
+type HTTPSConfig struct {
+	// Host is used by HTTPSRedirect to override the host of the redirected url,
+	// which may contain the port, such as "example.com:8443".
+	//
+	// Optional. Default: "", which uses the host of the request.
+	Host string
+
+	// TrustedProxies is the list of the networks of the trusted proxies.
+	//
+	// Only if the remote address is a trusted proxy, the scheme headers set by
+	// the TLS-terminating proxy, such as "X-Forwarded-Proto", are honored.
+	// Or, only the request over TLS is regarded as https, so that the client
+	// cannot bypass the check by sending the scheme headers itself.
+	//
+	// Optional. Default: nil.
+	TrustedProxies []net.IPNet
+}
+
+// HTTPSRedirect returns a middleware to redirect the plaintext request
+// to the same url with the scheme "https" by 301.
+//
+// See HTTPSConfig about how to detect whether the request is https.
+func HTTPSRedirect(config ...HTTPSConfig) Middleware {
+	var conf HTTPSConfig
+	if len(config) > 0 {
+		conf = config[0]
+	}
+
+	return func(next ship.Handler) ship.Handler {
+		return func(c *ship.Context) error {
+			if isHTTPS(c, conf.TrustedProxies) {
+				return next(c)
+			}
+
+			h := conf.Host
+			if h == "" {
+				h = c.Host()
+			}
+			return c.Redirect(http.StatusMovedPermanently,
+				"https://"+h+c.Request().URL.RequestURI())
+		}
+	}
+}
+
+// HTTPSOnly returns a middleware to reject the plaintext request
+// with ErrForbidden, which is stricter than HTTPSRedirect.
+//
+// See HTTPSConfig about how to detect whether the request is https.
+func HTTPSOnly(config ...HTTPSConfig) Middleware {
+	var conf HTTPSConfig
+	if len(config) > 0 {
+		conf = config[0]
+	}
+
+	return func(next ship.Handler) ship.Handler {
+		return func(c *ship.Context) error {
+			if isHTTPS(c, conf.TrustedProxies) {
+				return next(c)
+			}
+			return ship.ErrForbidden.Newf("https is required")
+		}
+	}
+}
+
+func isHTTPS(c *ship.Context, proxies []net.IPNet) bool {
+	if c.IsTLS() {
+		return true
+	} else if len(proxies) == 0 {
+		return false
+	}
+
+	ip, _, err := net.SplitHostPort(c.RemoteAddr())
+	if err != nil {
+		ip = c.RemoteAddr()
+	}
+
+	if !containsIP(proxies, net.ParseIP(ip)) {
+		return false
+	}
+	return strings.EqualFold(c.Scheme(), "https")
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xgfone/ship/v5"
+)
+
+func TestHTTPSRedirect(t *testing.T) {
+	_, proxy, _ := net.ParseCIDR("10.0.0.0/8")
+
+	s := ship.New()
+	s.Use(HTTPSRedirect(HTTPSConfig{TrustedProxies: []net.IPNet{*proxy}}))
+	s.Route("/path").GET(ship.OkHandler())
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/path?a=1", nil))
+	if rec.Code != http.StatusMovedPermanently {
+		t.Errorf("expect status code %d, but got %d", http.StatusMovedPermanently, rec.Code)
+	} else if loc := rec.Header().Get(ship.HeaderLocation); loc != "https://example.com/path?a=1" {
+		t.Errorf("expect location '%s', but got '%s'", "https://example.com/path?a=1", loc)
+	}
+
+	// The scheme header from the untrusted client is ignored.
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/path", nil)
+	req.Header.Set(ship.HeaderXForwardedProto, "https")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMovedPermanently {
+		t.Errorf("expect status code %d, but got %d", http.StatusMovedPermanently, rec.Code)
+	}
+
+	// The scheme header from the trusted proxy is honored.
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/path", nil)
+	req.Header.Set(ship.HeaderXForwardedProto, "https")
+	req.RemoteAddr = "10.0.0.1:12345"
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expect status code %d, but got %d", http.StatusOK, rec.Code)
+	}
+
+	s = ship.New()
+	s.Use(HTTPSRedirect(HTTPSConfig{Host: "example.com:8443"}))
+	s.Route("/path").GET(ship.OkHandler())
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/path", nil))
+	if loc := rec.Header().Get(ship.HeaderLocation); loc != "https://example.com:8443/path" {
+		t.Errorf("expect location '%s', but got '%s'", "https://example.com:8443/path", loc)
+	}
+}
+
+func TestHTTPSOnly(t *testing.T) {
+	s := ship.New()
+	s.Use(HTTPSOnly())
+	s.Route("/path").GET(ship.OkHandler())
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/path", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expect status code %d, but got %d", http.StatusForbidden, rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/path", nil)
+	req.Header.Set(ship.HeaderXForwardedProto, "https")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expect status code %d, but got %d", http.StatusForbidden, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "https://example.com/path", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expect status code %d, but got %d", http.StatusOK, rec.Code)
+	}
+}
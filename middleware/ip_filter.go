@@ -0,0 +1,112 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"github.com/xgfone/ship/v5"
+)
+
+// IPFilterConfig is used to configure the IPFilter middleware.
+type IPFilterConfig struct {
+	// Allow is the list of the networks allowed to access the resource.
+	// If empty, all are allowed except those in Deny.
+	//
+	// Optional. Default: nil.
+	Allow []net.IPNet
+
+	// Deny is the list of the networks denied to access the resource,
+	// which takes precedence over Allow.
+	//
+	// Optional. Default: nil.
+	Deny []net.IPNet
+
+	// TrustedProxies is the list of the networks of the trusted proxies.
+	//
+	// If the remote address is a trusted proxy, the client ip is the rightmost
+	// address of the header "X-Forwarded-For" that is not a trusted proxy.
+	// Or, it is the remote address, so the header "X-Forwarded-For" is ignored
+	// if there are no trusted proxies, which cannot be spoofed by the client.
+	//
+	// Optional. Default: nil.
+	TrustedProxies []net.IPNet
+
+	// ClientIP is used to get the client ip from the request,
+	// which will override TrustedProxies if set.
+	// For example, set it to (*ship.Context).ClientIP to trust
+	// the headers "X-Forwarded-For" and "X-Real-Ip" unconditionally.
+	//
+	// Optional. Default: nil.
+	ClientIP func(*ship.Context) string
+}
+
+// IPFilter returns a middleware to allow or deny the request
+// by the client ip, which returns ship.ErrForbidden if denied.
+func IPFilter(config IPFilterConfig) Middleware {
+	getClientIP := config.ClientIP
+	if getClientIP == nil {
+		getClientIP = func(c *ship.Context) string {
+			return clientIPWithTrustedProxies(c, config.TrustedProxies)
+		}
+	}
+
+	return func(next ship.Handler) ship.Handler {
+		return func(c *ship.Context) error {
+			ip := net.ParseIP(getClientIP(c))
+			if ip == nil || containsIP(config.Deny, ip) ||
+				(len(config.Allow) > 0 && !containsIP(config.Allow, ip)) {
+				return ship.ErrForbidden
+			}
+			return next(c)
+		}
+	}
+}
+
+func containsIP(nets []net.IPNet, ip net.IP) bool {
+	for i := range nets {
+		if nets[i].Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func clientIPWithTrustedProxies(c *ship.Context, proxies []net.IPNet) string {
+	ip, _, err := net.SplitHostPort(c.RemoteAddr())
+	if err != nil {
+		ip = c.RemoteAddr()
+	}
+
+	if len(proxies) == 0 || !containsIP(proxies, net.ParseIP(ip)) {
+		return ip
+	}
+
+	xff := c.GetReqHeader(ship.HeaderXForwardedFor)
+	for ips := strings.Split(xff, ","); len(ips) > 0; ips = ips[:len(ips)-1] {
+		addr := strings.TrimSpace(ips[len(ips)-1])
+		if addr == "" {
+			continue
+		}
+
+		ip = addr
+		if !containsIP(proxies, net.ParseIP(ip)) {
+			break
+		}
+	}
+
+	return ip
+}
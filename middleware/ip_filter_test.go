@@ -0,0 +1,87 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xgfone/ship/v5"
+)
+
+func parseIPNets(cidrs ...string) []net.IPNet {
+	nets := make([]net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = *ipnet
+	}
+	return nets
+}
+
+func TestIPFilter(t *testing.T) {
+	s := ship.New()
+	s.Use(IPFilter(IPFilterConfig{
+		Allow:          parseIPNets("10.0.0.0/8"),
+		Deny:           parseIPNets("10.1.0.0/16"),
+		TrustedProxies: parseIPNets("192.168.0.0/16"),
+	}))
+	s.Route("/").GET(func(c *ship.Context) error { return c.NoContent(200) })
+
+	tests := []struct {
+		remote string
+		xff    string
+		code   int
+	}{
+		{remote: "10.0.0.1:1234", code: 200},
+		{remote: "10.1.0.1:1234", code: 403},
+		{remote: "172.16.0.1:1234", code: 403},
+		{remote: "10.1.0.1:1234", xff: "10.0.0.1", code: 403}, // untrusted proxy
+		{remote: "192.168.0.1:1234", xff: "10.0.0.1", code: 200},
+		{remote: "192.168.0.1:1234", xff: "10.0.0.1, 192.168.0.2", code: 200},
+		{remote: "192.168.0.1:1234", xff: "10.0.0.1, 10.1.0.1", code: 403},
+		{remote: "192.168.0.1:1234", xff: "10.1.0.1, 10.0.0.1", code: 200},
+	}
+
+	for i, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = test.remote
+		if test.xff != "" {
+			req.Header.Set(ship.HeaderXForwardedFor, test.xff)
+		}
+
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != test.code {
+			t.Errorf("%d: expect status code %d, but got %d", i, test.code, rec.Code)
+		}
+	}
+
+	s = ship.New()
+	s.Use(IPFilter(IPFilterConfig{Deny: parseIPNets("10.1.0.0/16")}))
+	s.Route("/").GET(func(c *ship.Context) error { return c.NoContent(200) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "172.16.0.1:1234"
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, rec.Code)
+	}
+}
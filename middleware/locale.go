@@ -0,0 +1,40 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import "github.com/xgfone/ship/v5"
+
+// Locale returns a middleware to select the locale of the request
+// from the supported languages by Context.PreferredLanguage, then store it
+// into Context.Data by the key ship.LocaleDataKey, which is used by Context.T,
+// and set the response header "Content-Language".
+//
+// If languages is empty, use the languages of Context.MessageBundle.
+func Locale(languages ...string) Middleware {
+	return func(next ship.Handler) ship.Handler {
+		return func(c *ship.Context) error {
+			langs := languages
+			if len(langs) == 0 && c.MessageBundle != nil {
+				langs = c.MessageBundle.Languages()
+			}
+
+			if locale := c.PreferredLanguage(langs...); locale != "" {
+				c.Data[ship.LocaleDataKey] = locale
+				c.SetRespHeader(ship.HeaderContentLanguage, locale)
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xgfone/ship/v5"
+)
+
+func TestLocale(t *testing.T) {
+	s := ship.New()
+	s.MessageBundle = ship.NewMapMessageBundle("en", map[string]map[string]string{
+		"en": {"hello": "Hello, %s", "bye": "Bye"},
+		"zh": {"hello": "你好, %s"},
+	})
+	s.Use(Locale())
+	s.Route("/hello").GET(func(c *ship.Context) error {
+		return c.Text(200, c.T("hello", "ship")+"|"+c.T("bye")+"|"+c.T("unknown"))
+	})
+
+	for lang, expect := range map[string]string{
+		"zh-CN,zh;q=0.9": "你好, ship|Bye|unknown",
+		"en-US":          "Hello, ship|Bye|unknown",
+		"fr":             "Hello, ship|Bye|unknown",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+		req.Header.Set(ship.HeaderAcceptLanguage, lang)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if body := rec.Body.String(); body != expect {
+			t.Errorf("%s: expect '%s', but got '%s'", lang, expect, body)
+		}
+	}
+}
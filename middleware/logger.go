@@ -15,7 +15,9 @@
 package middleware
 
 import (
+	"math"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/xgfone/ship/v5"
@@ -23,8 +25,32 @@ import (
 
 const logfmt = "addr=%s, method=%s, path=%s, code=%d, starttime=%d, cost=%s, err=%v"
 
+// LoggerConfig is used to configure the Logger middleware.
+type LoggerConfig struct {
+	// Sample is the fraction of the successful requests to be logged,
+	// which is between 0 and 1. But the requests with the status code
+	// 400 or above, or slower than SlowThreshold, are always logged.
+	//
+	// Optional. Default: 0, which is equal to 1 and logs all the requests.
+	Sample float64
+
+	// SlowThreshold is the latency threshold, and the request slower than it
+	// is always logged whatever Sample is.
+	//
+	// Optional. Default: 0, which is disabled.
+	SlowThreshold time.Duration
+}
+
 // Logger returns a new logger middleware that will log the request.
-func Logger() Middleware {
+//
+// If the config is not given, use the default.
+func Logger(config ...LoggerConfig) Middleware {
+	var conf LoggerConfig
+	if len(config) > 0 {
+		conf = config[0]
+	}
+
+	sampled := newSampler(conf.Sample)
 	return func(next ship.Handler) ship.Handler {
 		return func(ctx *ship.Context) (err error) {
 			start := time.Now()
@@ -40,6 +66,11 @@ func Logger() Middleware {
 				}
 			}
 
+			if code < 400 && !(conf.SlowThreshold > 0 && cost > conf.SlowThreshold) &&
+				!sampled() {
+				return
+			}
+
 			var logf func(string, ...interface{})
 			if code < 400 {
 				logf = ctx.Infof
@@ -57,3 +88,26 @@ func Logger() Middleware {
 		}
 	}
 }
+
+// newSampler returns a function to report whether to sample the current
+// request by the fraction, which uses the lock-free pseudo-random sequence
+// instead of math/rand with the global lock.
+func newSampler(fraction float64) func() bool {
+	if fraction <= 0 || fraction >= 1 {
+		return func() bool { return true }
+	}
+
+	threshold := uint64(math.MaxUint64)
+	if f := fraction * float64(math.MaxUint64); f < float64(math.MaxUint64) {
+		threshold = uint64(f)
+	}
+
+	seed := uint64(time.Now().UnixNano())
+	return func() bool {
+		// SplitMix64
+		z := atomic.AddUint64(&seed, 0x9E3779B97F4A7C15)
+		z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+		z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+		return z^(z>>31) < threshold
+	}
+}
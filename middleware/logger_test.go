@@ -20,6 +20,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/xgfone/ship/v5"
 )
@@ -52,3 +53,34 @@ func TestLogger(t *testing.T) {
 		t.Error(s)
 	}
 }
+
+func TestLoggerSample(t *testing.T) {
+	countLogs := func(conf LoggerConfig, path string, n int) int {
+		bs := bytes.NewBuffer(nil)
+		router := ship.New()
+		router.Logger = ship.NewLoggerFromWriter(bs, "", 0)
+		router.HandleError = func(*ship.Context, error) {}
+		router.Use(Logger(conf))
+		router.Route("/ok").GET(ship.OkHandler())
+		router.Route("/err").GET(func(*ship.Context) error { return ship.ErrBadRequest })
+
+		for i := 0; i < n; i++ {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		return strings.Count(bs.String(), "\n")
+	}
+
+	if n := countLogs(LoggerConfig{}, "/ok", 100); n != 100 {
+		t.Errorf("expect 100 logs, but got %d", n)
+	}
+	if n := countLogs(LoggerConfig{Sample: 0.5}, "/ok", 1000); n < 350 || n > 650 {
+		t.Errorf("expect about 500 logs, but got %d", n)
+	}
+	if n := countLogs(LoggerConfig{Sample: 0.001}, "/err", 100); n != 100 {
+		t.Errorf("expect 100 error logs, but got %d", n)
+	}
+	if n := countLogs(LoggerConfig{Sample: 0.001, SlowThreshold: time.Nanosecond}, "/ok", 100); n != 100 {
+		t.Errorf("expect 100 slow logs, but got %d", n)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/xgfone/ship/v5"
+)
+
+// MethodOverrideConfig is used to configure the MethodOverride middleware.
+type MethodOverrideConfig struct {
+	// Header is the request header containing the intended method.
+	//
+	// Optional. Default: "X-HTTP-Method-Override".
+	Header string
+
+	// FormField is the form field in the request body containing
+	// the intended method, which is only parsed for the request
+	// with the form Content-Type and without the header above.
+	//
+	// Optional. Default: "_method".
+	FormField string
+
+	// AllowMethods is the set of the methods that POST may be overridden to.
+	// The other methods are ignored and the request is kept as POST.
+	//
+	// Optional. Default: []string{"PUT", "PATCH", "DELETE"}.
+	AllowMethods []string
+}
+
+// MethodOverride returns a middleware to override the method of the POST
+// request by the header or the form field, for the HTML forms and proxies
+// which can only send GET and POST.
+//
+// Notice: it must be registered as the pre-middleware by Ship.Pre,
+// which is executed before finding the route. If registered by Ship.Use,
+// the route has been matched by the original method POST.
+//
+// Example
+//
+//    s := ship.New()
+//    s.Pre(middleware.MethodOverride(nil))
+//    s.Route("/users/:id").PUT(updateUser).DELETE(deleteUser)
+func MethodOverride(config *MethodOverrideConfig) Middleware {
+	var conf MethodOverrideConfig
+	if config != nil {
+		conf = *config
+	}
+
+	if conf.Header == "" {
+		conf.Header = ship.HeaderXHTTPMethodOverride
+	}
+	if conf.FormField == "" {
+		conf.FormField = "_method"
+	}
+	if len(conf.AllowMethods) == 0 {
+		conf.AllowMethods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+
+	allowed := make(map[string]struct{}, len(conf.AllowMethods))
+	for _, method := range conf.AllowMethods {
+		allowed[strings.ToUpper(method)] = struct{}{}
+	}
+
+	return func(next ship.Handler) ship.Handler {
+		return func(c *ship.Context) error {
+			req := c.Request()
+			if req.Method != http.MethodPost {
+				return next(c)
+			}
+
+			method := req.Header.Get(conf.Header)
+			if method == "" {
+				switch c.ContentType() {
+				case ship.MIMEApplicationForm, ship.MIMEMultipartForm:
+					method = req.PostFormValue(conf.FormField)
+				}
+			}
+
+			if method = strings.ToUpper(strings.TrimSpace(method)); method != "" {
+				if _, ok := allowed[method]; ok {
+					req.Method = method
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
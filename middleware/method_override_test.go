@@ -0,0 +1,75 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xgfone/ship/v5"
+)
+
+func TestMethodOverride(t *testing.T) {
+	s := ship.New()
+	s.Pre(MethodOverride(nil))
+	handler := func(c *ship.Context) error { return c.Text(200, c.Method()) }
+	s.Route("/path").Map(map[string]ship.Handler{
+		http.MethodGet:    handler,
+		http.MethodPost:   handler,
+		http.MethodPut:    handler,
+		http.MethodPatch:  handler,
+		http.MethodDelete: handler,
+	})
+
+	tests := []struct {
+		method string
+		header string
+		form   string
+		expect string
+	}{
+		{method: http.MethodPost, header: "PUT", expect: http.MethodPut},
+		{method: http.MethodPost, header: "patch", expect: http.MethodPatch},
+		{method: http.MethodPost, form: "DELETE", expect: http.MethodDelete},
+		{method: http.MethodPost, header: "PUT", form: "DELETE", expect: http.MethodPut},
+		{method: http.MethodPost, header: "GET", expect: http.MethodPost},
+		{method: http.MethodPost, form: "CONNECT", expect: http.MethodPost},
+		{method: http.MethodPost, expect: http.MethodPost},
+		{method: http.MethodGet, header: "DELETE", expect: http.MethodGet},
+	}
+
+	for _, test := range tests {
+		var req *http.Request
+		if test.form != "" {
+			body := strings.NewReader("_method=" + test.form)
+			req = httptest.NewRequest(test.method, "/path", body)
+			req.Header.Set(ship.HeaderContentType, ship.MIMEApplicationForm)
+		} else {
+			req = httptest.NewRequest(test.method, "/path", nil)
+		}
+		if test.header != "" {
+			req.Header.Set(ship.HeaderXHTTPMethodOverride, test.header)
+		}
+
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != 200 {
+			t.Errorf("expect status code %d, but got %d", 200, rec.Code)
+		} else if body := rec.Body.String(); body != test.expect {
+			t.Errorf("expect method '%s', but got '%s'", test.expect, body)
+		}
+	}
+}
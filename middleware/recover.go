@@ -16,21 +16,80 @@ package middleware
 
 import (
 	"fmt"
+	"runtime"
+	"strings"
 
 	"github.com/xgfone/ship/v5"
 )
 
+// RecoverConfig is used to configure the Recover middleware.
+type RecoverConfig struct {
+	// StackFormatter formats the stack of the panic from the program
+	// counters, which starts from the frame panicking.
+	//
+	// Optional. Default: FormatStack.
+	StackFormatter func(pc []uintptr) string
+
+	// Reporter is called with the recovered value and the formatted stack
+	// when the handler panics, which may be used to log the stack or send
+	// the panic to the external monitoring service.
+	//
+	// The panic is always returned as the error, which is handled by
+	// the outer middlewares such as Logger and the HandleError of Ship,
+	// so Reporter does not need to log the panic value again.
+	//
+	// Optional. Default: nil, that's, only return the panic as the error.
+	Reporter func(ctx *ship.Context, value interface{}, stack string)
+}
+
+// FormatStack formats the stack of the program counters like
+// runtime/debug.Stack, but skips the frames of the runtime package.
+func FormatStack(pc []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pc)
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
 // Recover returns a middleware to wrap the panic.
-func Recover() Middleware {
+//
+// If the config is not given, use the default.
+func Recover(config ...RecoverConfig) Middleware {
+	var conf RecoverConfig
+	if len(config) > 0 {
+		conf = config[0]
+	}
+	if conf.StackFormatter == nil {
+		conf.StackFormatter = FormatStack
+	}
+
 	return func(next ship.Handler) ship.Handler {
 		return func(ctx *ship.Context) (err error) {
 			defer func() {
-				switch e := recover().(type) {
+				e := recover()
+				switch v := e.(type) {
 				case nil:
+					return
 				case error:
-					err = e
+					err = v
 				default:
-					err = fmt.Errorf("%v", e)
+					err = fmt.Errorf("%v", v)
+				}
+
+				if conf.Reporter != nil {
+					// Skip runtime.Callers and the current deferred function.
+					pc := make([]uintptr, 64)
+					pc = pc[:runtime.Callers(2, pc)]
+					conf.Reporter(ctx, e, conf.StackFormatter(pc))
 				}
 			}()
 			return next(ctx)
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/xgfone/ship/v5"
@@ -25,7 +26,9 @@ import (
 
 func TestRecover(t *testing.T) {
 	bs := bytes.NewBuffer(nil)
+	logs := bytes.NewBuffer(nil)
 	router := ship.New()
+	router.Logger = ship.NewLoggerFromWriter(logs, "")
 	router.Use(Recover())
 	router.HandleError = func(ctx *ship.Context, err error) {
 		bs.WriteString(err.Error())
@@ -42,4 +45,36 @@ func TestRecover(t *testing.T) {
 	if bs.String() != "test panic" {
 		t.Fail()
 	}
+	if logs.Len() > 0 {
+		t.Errorf("unexpected the logs: %s", logs.String())
+	}
+}
+
+func TestRecoverConfig(t *testing.T) {
+	var value interface{}
+	var stack string
+	router := ship.New()
+	router.Use(Recover(RecoverConfig{
+		Reporter: func(ctx *ship.Context, v interface{}, s string) {
+			value, stack = v, s
+		},
+	}))
+	router.HandleError = func(ctx *ship.Context, err error) {}
+
+	router.Route("/panic").GET(func(ctx *ship.Context) error {
+		panic("test panic")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if value != "test panic" {
+		t.Errorf("expect the panic value '%s', but got '%v'", "test panic", value)
+	}
+	if !strings.Contains(stack, "TestRecoverConfig") {
+		t.Errorf("the stack does not contain the panicking function: %s", stack)
+	} else if strings.Contains(stack, "runtime.gopanic") {
+		t.Errorf("the stack contains the runtime frames: %s", stack)
+	}
 }
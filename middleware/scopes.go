@@ -0,0 +1,76 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"strings"
+
+	"github.com/xgfone/ship/v5"
+)
+
+// ScopesDataKey is the key of Context.Data to store the granted scopes
+// of the authenticated principal, the value of which must be []string.
+//
+// It should be set by the authentication middleware or handler.
+const ScopesDataKey = "scopes"
+
+// RequireScopes returns a middleware to check whether the authenticated
+// principal has been granted all the required scopes, which are the union of
+// the given scopes and the scopes of the matched route set by
+// RouteBuilder.Scopes. So it may be registered as a global middleware
+// only once, and the routes declare their required scopes. For example,
+//
+//    router.Use(authenticate, RequireScopes())
+//    router.Route("/users").Scopes("user:read").GET(listUsers)
+//    router.Route("/users").Scopes("user:write").POST(createUser)
+//
+// The granted scopes are read from Context.Data by the key ScopesDataKey.
+// If some required scopes are missing, it returns ErrForbidden
+// with the missing scopes.
+func RequireScopes(scopes ...string) Middleware {
+	return func(next ship.Handler) ship.Handler {
+		return func(c *ship.Context) error {
+			granted, _ := c.Data[ScopesDataKey].([]string)
+
+			var missings []string
+			missings = appendMissingScopes(missings, scopes, granted)
+			missings = appendMissingScopes(missings, c.RouteScopes(), granted)
+			if len(missings) > 0 {
+				return ship.ErrForbidden.Newf("missing scopes: %s",
+					strings.Join(missings, ", "))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func appendMissingScopes(missings, required, granted []string) []string {
+	for _, scope := range required {
+		if !inStrings(scope, granted) && !inStrings(scope, missings) {
+			missings = append(missings, scope)
+		}
+	}
+	return missings
+}
+
+func inStrings(s string, ss []string) bool {
+	for i, _len := 0, len(ss); i < _len; i++ {
+		if ss[i] == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xgfone/ship/v5"
+)
+
+func TestRequireScopes(t *testing.T) {
+	authenticate := func(next ship.Handler) ship.Handler {
+		return func(c *ship.Context) error {
+			if scopes := c.GetReqHeader("X-Scopes"); scopes != "" {
+				c.Data[ScopesDataKey] = strings.Split(scopes, ",")
+			}
+			return next(c)
+		}
+	}
+
+	s := ship.New()
+	s.Use(authenticate, RequireScopes("login"))
+	s.Route("/users").Scopes("user:read").GET(ship.OkHandler())
+	s.Route("/users").Scopes("user:write").POST(ship.OkHandler())
+	s.Route("/chain").Scopes("chain:read").GET(ship.OkHandler()).
+		Scopes("chain:write").POST(ship.OkHandler())
+
+	checkPath := func(method, path, scopes string, code int, body string) {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Scopes", scopes)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != code {
+			t.Errorf("%s %s %s: expect status code %d, but got %d", method, path, scopes, code, rec.Code)
+		} else if body != "" && rec.Body.String() != body {
+			t.Errorf("%s %s %s: expect body '%s', but got '%s'", method, path, scopes, body, rec.Body.String())
+		}
+	}
+	check := func(method, scopes string, code int, body string) {
+		checkPath(method, "/users", scopes, code, body)
+	}
+
+	check(http.MethodGet, "login,user:read", 200, "")
+	check(http.MethodGet, "login", 403, "missing scopes: user:read")
+	check(http.MethodPost, "login,user:read", 403, "missing scopes: user:write")
+	check(http.MethodPost, "", 403, "missing scopes: login, user:write")
+	check(http.MethodPost, "login,user:write", 200, "")
+
+	// The chained Scopes must not modify the scopes of the built routes.
+	checkPath(http.MethodGet, "/chain", "login,chain:read", 200, "")
+	checkPath(http.MethodGet, "/chain", "login,chain:write", 403, "missing scopes: chain:read")
+	checkPath(http.MethodPost, "/chain", "login,chain:read", 403, "missing scopes: chain:write")
+	checkPath(http.MethodPost, "/chain", "login,chain:write", 200, "")
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/xgfone/ship/v5"
+)
+
+// SecureConfig is used to configure the Secure middleware.
+//
+// The response header is omitted if its config value is empty.
+type SecureConfig struct {
+	// ContentTypeNosniff is the value of the header "X-Content-Type-Options".
+	//
+	// Optional. Default: "".
+	ContentTypeNosniff string
+
+	// XFrameOptions is the value of the header "X-Frame-Options",
+	// such as "DENY", "SAMEORIGIN".
+	//
+	// Optional. Default: "".
+	XFrameOptions string
+
+	// HSTSMaxAge is the max-age in seconds of the header
+	// "Strict-Transport-Security", which is only set for the TLS request.
+	//
+	// Optional. Default: 0.
+	HSTSMaxAge int
+
+	// HSTSIncludeSubdomains indicates whether to add "includeSubDomains"
+	// into the header "Strict-Transport-Security".
+	//
+	// Optional. Default: false.
+	HSTSIncludeSubdomains bool
+
+	// HSTSPreload indicates whether to add "preload"
+	// into the header "Strict-Transport-Security".
+	//
+	// Optional. Default: false.
+	HSTSPreload bool
+
+	// ContentSecurityPolicy is the value of the header "Content-Security-Policy".
+	//
+	// Optional. Default: "".
+	ContentSecurityPolicy string
+
+	// ReferrerPolicy is the value of the header "Referrer-Policy".
+	//
+	// Optional. Default: "".
+	ReferrerPolicy string
+}
+
+// DefaultSecureConfig is the default config used by Secure when config is nil.
+var DefaultSecureConfig = SecureConfig{
+	ContentTypeNosniff: "nosniff",
+	XFrameOptions:      "SAMEORIGIN",
+	HSTSMaxAge:         31536000,
+	ReferrerPolicy:     "strict-origin-when-cross-origin",
+}
+
+// Secure returns a middleware to set the security response headers.
+// If config is nil, use DefaultSecureConfig.
+//
+// It sets the headers before calling the next handler, so it can be used
+// together with the CORS middleware in any order, because they set
+// the different headers. But it should be used before the middlewares
+// which may respond to the client directly, such as the preflight request
+// handled by CORS, if the security headers are also required for them.
+func Secure(config *SecureConfig) Middleware {
+	conf := DefaultSecureConfig
+	if config != nil {
+		conf = *config
+	}
+
+	var hsts string
+	if conf.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", conf.HSTSMaxAge)
+		if conf.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if conf.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	return func(next ship.Handler) ship.Handler {
+		return func(ctx *ship.Context) error {
+			if conf.ContentTypeNosniff != "" {
+				ctx.SetRespHeader(ship.HeaderXContentTypeOptions, conf.ContentTypeNosniff)
+			}
+			if conf.XFrameOptions != "" {
+				ctx.SetRespHeader(ship.HeaderXFrameOptions, conf.XFrameOptions)
+			}
+			if hsts != "" && ctx.IsTLS() {
+				ctx.SetRespHeader(ship.HeaderStrictTransportSecurity, hsts)
+			}
+			if conf.ContentSecurityPolicy != "" {
+				ctx.SetRespHeader(ship.HeaderContentSecurityPolicy, conf.ContentSecurityPolicy)
+			}
+			if conf.ReferrerPolicy != "" {
+				ctx.SetRespHeader(ship.HeaderReferrerPolicy, conf.ReferrerPolicy)
+			}
+			return next(ctx)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xgfone/ship/v5"
+)
+
+func TestSecure(t *testing.T) {
+	s := ship.New()
+	s.Route("/default").Use(Secure(nil)).GET(ship.OkHandler())
+	s.Route("/custom").Use(Secure(&SecureConfig{
+		HSTSMaxAge:            100,
+		HSTSIncludeSubdomains: true,
+		ContentSecurityPolicy: "default-src 'self'",
+	})).GET(ship.OkHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/default", nil)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if v := rec.Header().Get(ship.HeaderXContentTypeOptions); v != "nosniff" {
+		t.Errorf("unexpected header %s: %s", ship.HeaderXContentTypeOptions, v)
+	} else if v := rec.Header().Get(ship.HeaderXFrameOptions); v != "SAMEORIGIN" {
+		t.Errorf("unexpected header %s: %s", ship.HeaderXFrameOptions, v)
+	} else if v := rec.Header().Get(ship.HeaderStrictTransportSecurity); v != "" {
+		t.Errorf("unexpected header %s for the non-TLS request: %s", ship.HeaderStrictTransportSecurity, v)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/custom", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if v := rec.Header().Get(ship.HeaderStrictTransportSecurity); v != "max-age=100; includeSubDomains" {
+		t.Errorf("unexpected header %s: %s", ship.HeaderStrictTransportSecurity, v)
+	} else if v := rec.Header().Get(ship.HeaderContentSecurityPolicy); v != "default-src 'self'" {
+		t.Errorf("unexpected header %s: %s", ship.HeaderContentSecurityPolicy, v)
+	} else if v := rec.Header().Get(ship.HeaderXContentTypeOptions); v != "" {
+		t.Errorf("unexpected header %s: %s", ship.HeaderXContentTypeOptions, v)
+	}
+}
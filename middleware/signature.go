@@ -0,0 +1,113 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"strings"
+
+	"github.com/xgfone/ship/v5"
+)
+
+// SignatureConfig is used to configure the VerifySignature middleware.
+type SignatureConfig struct {
+	// Secret is the secret key of HMAC.
+	//
+	// Required.
+	Secret []byte
+
+	// Header is the name of the request header carrying the signature.
+	//
+	// Optional. Default: "X-Hub-Signature-256".
+	Header string
+
+	// Prefix is the prefix of the signature in the header to be removed,
+	// such as "sha256=" used by GitHub.
+	//
+	// Optional. Default: "".
+	Prefix string
+
+	// Hash is used to new the hash of HMAC.
+	//
+	// Optional. Default: sha256.New.
+	Hash func() hash.Hash
+
+	// Encoding is the encoding of the signature, "hex" or "base64".
+	//
+	// Optional. Default: "hex".
+	Encoding string
+}
+
+// VerifySignature returns a middleware to verify the HMAC signature
+// of the request body, which responds with ErrUnauthorized if the signature
+// is missing or mismatched.
+//
+// It reads the request body by Context.RawBody, which is limited by
+// Context.MaxBodySize, so the handler is still able to read or bind
+// the request body. For example, verify the GitHub webhook:
+//
+//    VerifySignature(SignatureConfig{Secret: secret, Prefix: "sha256="})
+func VerifySignature(config SignatureConfig) Middleware {
+	if len(config.Secret) == 0 {
+		panic("VerifySignature: the secret must not be empty")
+	}
+	if config.Header == "" {
+		config.Header = "X-Hub-Signature-256"
+	}
+	if config.Hash == nil {
+		config.Hash = sha256.New
+	}
+
+	var decode func(string) ([]byte, error)
+	switch config.Encoding {
+	case "", "hex":
+		decode = hex.DecodeString
+	case "base64":
+		decode = base64.StdEncoding.DecodeString
+	default:
+		panic("VerifySignature: unknown signature encoding '" + config.Encoding + "'")
+	}
+
+	return func(next ship.Handler) ship.Handler {
+		return func(c *ship.Context) error {
+			signature := c.GetReqHeader(config.Header)
+			if signature == "" || !strings.HasPrefix(signature, config.Prefix) {
+				return ship.ErrUnauthorized.Newf("missing signature")
+			}
+
+			expected, err := decode(signature[len(config.Prefix):])
+			if err != nil {
+				return ship.ErrUnauthorized.Newf("invalid signature")
+			}
+
+			body, err := c.RawBody()
+			if err != nil {
+				return err
+			}
+
+			mac := hmac.New(config.Hash, config.Secret)
+			mac.Write(body)
+			if !hmac.Equal(mac.Sum(nil), expected) {
+				return ship.ErrUnauthorized.Newf("signature mismatch")
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xgfone/ship/v5"
+)
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("secret")
+	body := `{"name":"abc"}`
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(body))
+	sum := mac.Sum(nil)
+
+	handler := func(c *ship.Context) error {
+		var v struct {
+			Name string `json:"name"`
+		}
+		if err := c.Bind(&v); err != nil {
+			return err
+		}
+		return c.Text(200, v.Name)
+	}
+
+	s := ship.Default()
+	s.Route("/hex").
+		Use(VerifySignature(SignatureConfig{Secret: secret, Prefix: "sha256="})).
+		POST(handler)
+	s.Route("/base64").
+		Use(VerifySignature(SignatureConfig{
+			Secret: secret, Header: "X-Signature", Encoding: "base64"})).
+		POST(handler)
+
+	check := func(path, header, signature string, code int) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(ship.HeaderContentType, ship.MIMEApplicationJSON)
+		if signature != "" {
+			req.Header.Set(header, signature)
+		}
+
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != code {
+			t.Errorf("%s: expect status code %d, but got %d", path, code, rec.Code)
+		} else if code == 200 && rec.Body.String() != "abc" {
+			t.Errorf("%s: expect body '%s', but got '%s'", path, "abc", rec.Body.String())
+		}
+	}
+
+	check("/hex", "X-Hub-Signature-256", "sha256="+hex.EncodeToString(sum), 200)
+	check("/hex", "X-Hub-Signature-256", hex.EncodeToString(sum), 401)
+	check("/hex", "X-Hub-Signature-256", "sha256=00", 401)
+	check("/hex", "X-Hub-Signature-256", "", 401)
+	check("/base64", "X-Signature", base64.StdEncoding.EncodeToString(sum), 200)
+	check("/base64", "X-Signature", hex.EncodeToString(sum), 401)
+}
@@ -0,0 +1,40 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import "github.com/xgfone/ship/v5"
+
+// When returns a middleware to run the middleware m only when predicate
+// returns true for the request. Or, call the next handler directly.
+//
+// The handler wrapped by m is built only once, and predicate is evaluated
+// for each request, so it should be cheap.
+func When(predicate func(*ship.Context) bool, m Middleware) Middleware {
+	if predicate == nil {
+		panic("When: the predicate must not be nil")
+	} else if m == nil {
+		panic("When: the middleware must not be nil")
+	}
+
+	return func(next ship.Handler) ship.Handler {
+		wrapped := m(next)
+		return func(c *ship.Context) error {
+			if predicate(c) {
+				return wrapped(c)
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xgfone/ship/v5"
+)
+
+func TestWhen(t *testing.T) {
+	setHeader := func(next ship.Handler) ship.Handler {
+		return func(c *ship.Context) error {
+			c.SetRespHeader("X-Api", "1")
+			return next(c)
+		}
+	}
+
+	isAPI := func(c *ship.Context) bool { return strings.HasPrefix(c.Path(), "/api/") }
+
+	s := ship.New()
+	s.Use(When(isAPI, setHeader))
+	s.Route("/api/users").GET(ship.OkHandler())
+	s.Route("/users").GET(ship.OkHandler())
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+	if v := rec.Header().Get("X-Api"); v != "1" {
+		t.Errorf("expect header X-Api '%s', but got '%s'", "1", v)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if v := rec.Header().Get("X-Api"); v != "" {
+		t.Errorf("unexpected header X-Api '%s'", v)
+	} else if rec.Code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, rec.Code)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapi supplies a minimal OpenAPI 3 specification generator
+// based on the routes registered into ship.
+package openapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/xgfone/ship/v5"
+)
+
+// Version is the version of the OpenAPI specification.
+const Version = "3.0.3"
+
+// DefaultInfoVersion is the default version of the API in Info.
+const DefaultInfoVersion = "1.0.0"
+
+// MetaKey is the key of the route metadata to store the Operation,
+// which is set by RouteBuilder.Meta. For example,
+//
+//    router.Route("/users").Meta(openapi.MetaKey, openapi.Operation{
+//        Summary: "create a user",
+//    }).POST(handler)
+const MetaKey = "openapi"
+
+// WildcardName is the name of the parameter of the bare wildcard "*",
+// which is not a valid name of the path parameter in OpenAPI.
+const WildcardName = "wildcard"
+
+// AnyMethods is the methods that the route with the empty method is expanded to.
+var AnyMethods = []string{
+	http.MethodGet,
+	http.MethodPut,
+	http.MethodPost,
+	http.MethodHead,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// Spec is the OpenAPI specification.
+type Spec struct {
+	OpenAPI string              `json:"openapi"`
+	Info    Info                `json:"info"`
+	Paths   map[string]PathItem `json:"paths"`
+}
+
+// Info is the metadata about the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem is the operations of a path, the key of which is the lower method.
+type PathItem map[string]*Operation
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string              `json:"operationId,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter describes a single operation parameter.
+type Parameter struct {
+	Name        string      `json:"name"`
+	In          string      `json:"in"` // "path", "query", "header" or "cookie"
+	Required    bool        `json:"required,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Schema      interface{} `json:"schema,omitempty"`
+}
+
+// RequestBody describes a single request body.
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// Response describes a single response from an API operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType provides the schema for the media type.
+type MediaType struct {
+	Schema interface{} `json:"schema,omitempty"`
+}
+
+// Generate generates the OpenAPI specification from the routes of s.
+//
+// If info is given, it is used as the metadata about the API. If the title
+// is empty, it is the name of s. If the version is empty, it is
+// DefaultInfoVersion.
+//
+// If the metadata of the route by MetaKey, which may be set by
+// RouteBuilder.Meta, or the data of the route, which may be set by
+// RouteBuilder.Data, is Operation or *Operation, it is used as the metadata
+// of the operation. The operationId is derived from the route name
+// and method if it is empty, and the path parameters are added automatically
+// if missing.
+func Generate(s *ship.Ship, info ...Info) *Spec {
+	spec := &Spec{OpenAPI: Version, Paths: make(map[string]PathItem, 16)}
+	if len(info) > 0 {
+		spec.Info = info[0]
+	}
+	if spec.Info.Title == "" {
+		spec.Info.Title = s.Name
+	}
+	if spec.Info.Version == "" {
+		spec.Info.Version = DefaultInfoVersion
+	}
+
+	for _, r := range s.Routes() {
+		path, pnames := ConvertPath(r.Path)
+		item, ok := spec.Paths[path]
+		if !ok {
+			item = make(PathItem, 4)
+			spec.Paths[path] = item
+		}
+
+		methods := []string{r.Method}
+		if r.Method == "" {
+			methods = AnyMethods
+		}
+
+		for _, method := range methods {
+			item[strings.ToLower(method)] = newOperation(r, method, pnames)
+		}
+	}
+
+	return spec
+}
+
+func newOperation(r ship.Route, method string, pnames []string) *Operation {
+	data, ok := r.Meta[MetaKey]
+	if !ok {
+		data = r.Data
+	}
+
+	var op Operation
+	switch v := data.(type) {
+	case Operation:
+		op = v
+	case *Operation:
+		if v != nil {
+			op = *v
+		}
+	}
+
+	if op.OperationID == "" && r.Name != "" {
+		op.OperationID = r.Name + "_" + strings.ToLower(method)
+	}
+
+	if len(op.Responses) == 0 {
+		op.Responses = map[string]Response{"default": {Description: "default response"}}
+	}
+
+	params := make([]Parameter, 0, len(op.Parameters)+len(pnames))
+	params = append(params, op.Parameters...)
+	for _, pname := range pnames {
+		if !hasPathParameter(op.Parameters, pname) {
+			params = append(params, Parameter{
+				Name:     pname,
+				In:       "path",
+				Required: true,
+				Schema:   map[string]string{"type": "string"},
+			})
+		}
+	}
+	if len(params) > 0 {
+		op.Parameters = params
+	}
+
+	return &op
+}
+
+func hasPathParameter(params []Parameter, name string) bool {
+	for _, p := range params {
+		if p.In == "path" && p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ConvertPath converts the route path with the parameters, such as ":param",
+// "*" or "*param", to the OpenAPI path template, such as "{param}",
+// and returns the names of the path parameters.
+//
+// For the bare wildcard parameter "*", the parameter name is WildcardName.
+func ConvertPath(path string) (newpath string, pnames []string) {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if seg == "" {
+			continue
+		}
+
+		if seg[0] == ':' {
+			pnames = append(pnames, seg[1:])
+			segs[i] = "{" + seg[1:] + "}"
+		} else if index := strings.IndexByte(seg, '*'); index > -1 {
+			name := seg[index+1:]
+			if name == "" {
+				name = WildcardName
+			}
+			pnames = append(pnames, name)
+			segs[i] = seg[:index] + "{" + name + "}"
+		}
+	}
+	return strings.Join(segs, "/"), pnames
+}
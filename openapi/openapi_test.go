@@ -0,0 +1,77 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"testing"
+
+	"github.com/xgfone/ship/v5"
+)
+
+func TestConvertPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		expect string
+		pnames int
+	}{
+		{path: "/path/to", expect: "/path/to", pnames: 0},
+		{path: "/path/:id", expect: "/path/{id}", pnames: 1},
+		{path: "/path/:id/to/*", expect: "/path/{id}/to/{wildcard}", pnames: 2},
+		{path: "/path/*all", expect: "/path/{all}", pnames: 1},
+		{path: "/raw*", expect: "/raw{wildcard}", pnames: 1},
+	}
+
+	for _, test := range tests {
+		if path, pnames := ConvertPath(test.path); path != test.expect {
+			t.Errorf("expect path '%s', but got '%s'", test.expect, path)
+		} else if len(pnames) != test.pnames {
+			t.Errorf("expect %d parameters, but got %d", test.pnames, len(pnames))
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	s := ship.New()
+	s.Route("/users/:id").Name("user").GET(ship.OkHandler())
+	s.Route("/users").Data(Operation{Summary: "create a user"}).POST(ship.OkHandler())
+	s.Route("/users").Data("data").Meta(MetaKey, &Operation{Summary: "list users"}).GET(ship.OkHandler())
+
+	spec := Generate(s, Info{Title: "users"})
+	if len(spec.Paths) != 2 {
+		t.Fatalf("expect %d paths, but got %d", 2, len(spec.Paths))
+	} else if spec.Info.Title != "users" || spec.Info.Version != DefaultInfoVersion {
+		t.Errorf("unexpected info %+v", spec.Info)
+	}
+
+	if op := spec.Paths["/users/{id}"]["get"]; op == nil {
+		t.Error("missing the operation 'GET /users/{id}'")
+	} else if op.OperationID != "user_get" {
+		t.Errorf("expect operationId '%s', but got '%s'", "user_get", op.OperationID)
+	} else if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" {
+		t.Errorf("unexpected parameters: %+v", op.Parameters)
+	}
+
+	if op := spec.Paths["/users"]["post"]; op == nil {
+		t.Error("missing the operation 'POST /users'")
+	} else if op.Summary != "create a user" {
+		t.Errorf("expect summary '%s', but got '%s'", "create a user", op.Summary)
+	}
+
+	if op := spec.Paths["/users"]["get"]; op == nil {
+		t.Error("missing the operation 'GET /users'")
+	} else if op.Summary != "list users" {
+		t.Errorf("expect summary '%s', but got '%s'", "list users", op.Summary)
+	}
+}
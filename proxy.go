@@ -0,0 +1,88 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+)
+
+// ProxyOptions is used to configure the reverse proxy handler.
+type ProxyOptions struct {
+	// StripPrefix is the prefix stripped from the request path
+	// before forwarding the request to the backend.
+	//
+	// Default: ""
+	StripPrefix string
+
+	// Director is used to modify the request after the default director,
+	// such as adding or deleting the request headers.
+	//
+	// Default: nil
+	Director func(*http.Request)
+
+	// Transport is used to forward the request to the backend.
+	//
+	// Default: http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// ProxyHandler returns a handler to forward the request to the target,
+// which is based on httputil.NewSingleHostReverseProxy.
+//
+// The client address is appended into the request header "X-Forwarded-For"
+// by httputil.ReverseProxy, and the error to forward the request is returned
+// as ErrBadGateway, which will be handled by Ship.HandleError.
+func ProxyHandler(target *url.URL, opts ProxyOptions) Handler {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = opts.Transport
+
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		if opts.StripPrefix != "" {
+			r.URL.Path = strings.TrimPrefix(r.URL.Path, opts.StripPrefix)
+			if r.URL.RawPath != "" {
+				r.URL.RawPath = strings.TrimPrefix(r.URL.RawPath, opts.StripPrefix)
+			}
+		}
+
+		director(r)
+		if opts.Director != nil {
+			opts.Director(r)
+		}
+	}
+
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if perr, ok := r.Context().Value(proxyErrorKey{}).(*proxyError); ok {
+			perr.err = err
+		}
+	}
+
+	return func(c *Context) error {
+		var perr proxyError
+		ctx := context.WithValue(c.req.Context(), proxyErrorKey{}, &perr)
+		proxy.ServeHTTP(c.res, c.req.WithContext(ctx))
+		if perr.err != nil {
+			return ErrBadGateway.New(perr.err)
+		}
+		return nil
+	}
+}
+
+type proxyErrorKey struct{}
+type proxyError struct{ err error }
@@ -0,0 +1,52 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestProxyHandler(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("X-Test")))
+	}))
+	defer backend.Close()
+
+	target, _ := url.Parse(backend.URL)
+	router := New()
+	router.Route("/api/*").GET(ProxyHandler(target, ProxyOptions{
+		StripPrefix: "/api",
+		Director:    func(r *http.Request) { r.Header.Set("X-Test", "test") },
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/path/to", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if s := rec.Body.String(); s != "/path/to test" {
+		t.Errorf("expect body '%s', but got '%s'", "/path/to test", s)
+	}
+
+	target, _ = url.Parse("http://127.0.0.1:1")
+	router.Route("/bad").GET(ProxyHandler(target, ProxyOptions{}))
+	req = httptest.NewRequest(http.MethodGet, "/bad", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expect status code %d, but got %d", http.StatusBadGateway, rec.Code)
+	}
+}
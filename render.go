@@ -15,8 +15,11 @@
 package ship
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"path"
 )
@@ -26,6 +29,13 @@ type Renderer interface {
 	Render(w http.ResponseWriter, name string, code int, data interface{}) error
 }
 
+// WriterRenderer is an optional interface implemented by the renderer
+// to render the content into any io.Writer instead of the http response,
+// such as generating the emails or caching the rendered output.
+type WriterRenderer interface {
+	RenderTo(w io.Writer, name string, data interface{}) error
+}
+
 // RendererFunc is the function type implementing the interface Renderer.
 type RendererFunc func(http.ResponseWriter, string, int, interface{}) error
 
@@ -74,10 +84,45 @@ func (mr *MuxRenderer) Del(suffix string) {
 
 // Render implements the interface Renderer, which will get the renderer
 // the name suffix then render the content.
+//
+// If the renderer has implemented the interface WriterRenderer and the MIME
+// type of the name suffix is known, such as "text/html; charset=utf-8"
+// for ".html", delegate to RenderTo and respond the rendered content
+// with the MIME type. Or, call the Render method of the renderer.
 func (mr *MuxRenderer) Render(w http.ResponseWriter, name string, code int,
 	data interface{}) error {
-	if renderer := mr.Get(name); renderer != nil {
-		return renderer.Render(w, name, code, data)
+	renderer := mr.Get(name)
+	if renderer == nil {
+		return fmt.Errorf("unknown renderer named '%s'", name)
+	}
+
+	if _, ok := renderer.(WriterRenderer); ok {
+		if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+			buf := bytes.NewBuffer(nil)
+			if err := mr.RenderTo(buf, name, data); err != nil {
+				return err
+			}
+
+			SetContentType(w.Header(), ct)
+			w.WriteHeader(code)
+			_, err := w.Write(buf.Bytes())
+			return err
+		}
+	}
+
+	return renderer.Render(w, name, code, data)
+}
+
+// RenderTo implements the interface WriterRenderer, which will get
+// the renderer by the name suffix then render the content into w.
+//
+// Return an error if the renderer has not implemented WriterRenderer.
+func (mr *MuxRenderer) RenderTo(w io.Writer, name string, data interface{}) error {
+	renderer := mr.Get(name)
+	if renderer == nil {
+		return fmt.Errorf("unknown renderer named '%s'", name)
+	} else if wr, ok := renderer.(WriterRenderer); ok {
+		return wr.RenderTo(w, name, data)
 	}
-	return fmt.Errorf("unknown renderer named '%s'", name)
+	return fmt.Errorf("the renderer named '%s' does not support RenderTo", name)
 }
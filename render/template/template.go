@@ -251,18 +251,29 @@ func (r *HTMLTemplateRender) execute(w io.Writer, name string, data interface{})
 	return tmpl.ExecuteTemplate(w, name, data)
 }
 
-// Render implements the interface render.Renderer.
-func (r *HTMLTemplateRender) Render(w http.ResponseWriter, name string, code int,
-	data interface{}) (err error) {
+func (r *HTMLTemplateRender) ensureLoaded() (err error) {
 	if r.debug {
-		if err = r.reload(); err != nil {
-			return
-		}
+		err = r.reload()
 	} else {
 		r.load.Do(func() { err = r.reload() })
-		if err != nil {
-			return
-		}
+	}
+	return
+}
+
+// RenderTo implements the interface ship.WriterRenderer,
+// which renders the html template into w.
+func (r *HTMLTemplateRender) RenderTo(w io.Writer, name string, data interface{}) (err error) {
+	if err = r.ensureLoaded(); err == nil {
+		err = r.execute(w, name, data)
+	}
+	return
+}
+
+// Render implements the interface render.Renderer.
+func (r *HTMLTemplateRender) Render(w http.ResponseWriter, name string, code int,
+	data interface{}) (err error) {
+	if err = r.ensureLoaded(); err != nil {
+		return
 	}
 
 	buf := r.bufs.Get().(*bytes.Buffer)
@@ -15,6 +15,7 @@
 package template
 
 import (
+	"bytes"
 	"io/ioutil"
 	"net/http/httptest"
 	"os"
@@ -102,4 +103,11 @@ func TestNewHTMLTemplateRender(t *testing.T) {
 	} else if body := rec.Body.String(); body != htmpresp {
 		t.Error(body)
 	}
+
+	buf := bytes.NewBuffer(nil)
+	if err = r.RenderTo(buf, tmplname, "This is the content."); err != nil {
+		t.Error(err)
+	} else if body := buf.String(); body != htmpresp {
+		t.Error(body)
+	}
 }
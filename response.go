@@ -19,6 +19,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strconv"
 	"sync"
 )
 
@@ -39,9 +40,21 @@ func PutResponseIntoPool(r *Response) { r.Reset(nil); responsePool.Put(r) }
 type Response struct {
 	http.ResponseWriter
 
-	Size   int64
-	Wrote  bool
+	// Size is the number of the bytes of the response body
+	// that have been written actually.
+	Size int64
+
+	// Wrote reports whether the response header has been sent.
+	Wrote bool
+
+	// Status is the status code of the response.
 	Status int
+
+	befores []func()
+	afters  []func()
+
+	filter func([]byte) []byte
+	buffer []byte
 }
 
 // NewResponse returns a new instance of Response.
@@ -54,7 +67,52 @@ func (r *Response) WriteHeader(code int) {
 	if !r.Wrote {
 		r.Wrote = true
 		r.Status = code
-		r.ResponseWriter.WriteHeader(code)
+		if r.filter == nil {
+			r.writeHeader()
+		}
+	}
+}
+
+func (r *Response) writeHeader() {
+	for _, f := range r.befores {
+		f()
+	}
+	r.ResponseWriter.WriteHeader(r.Status)
+}
+
+// Before registers the callback function f, which is called
+// just before the response header is sent, so it may be used
+// to modify the response header.
+//
+// All the before callbacks are called only once in turn.
+func (r *Response) Before(f func()) {
+	if f == nil {
+		panic("Response.Before: the callback function must not be nil")
+	}
+	r.befores = append(r.befores, f)
+}
+
+// After registers the callback function f, which is called
+// after the response has been finished.
+//
+// All the after callbacks are called only once in turn.
+func (r *Response) After(f func()) {
+	if f == nil {
+		panic("Response.After: the callback function must not be nil")
+	}
+	r.afters = append(r.afters, f)
+}
+
+// Finish sends the filtered response if the response filter is set,
+// then calls the after callbacks registered by After in turn,
+// which is called automatically by Ship when finishing the request.
+func (r *Response) Finish() {
+	r.flushFiltered()
+
+	afters := r.afters
+	r.afters = nil
+	for _, f := range afters {
+		f()
 	}
 }
 
@@ -65,6 +123,11 @@ func (r *Response) Write(b []byte) (n int, err error) {
 	}
 
 	r.WriteHeader(http.StatusOK)
+	if r.filter != nil {
+		r.buffer = append(r.buffer, b...)
+		return len(b), nil
+	}
+
 	n, err = r.ResponseWriter.Write(b)
 	r.Size += int64(n)
 	return
@@ -77,11 +140,61 @@ func (r *Response) WriteString(s string) (n int, err error) {
 	}
 
 	r.WriteHeader(http.StatusOK)
+	if r.filter != nil {
+		r.buffer = append(r.buffer, s...)
+		return len(s), nil
+	}
+
 	n, err = io.WriteString(r.ResponseWriter, s)
 	r.Size += int64(n)
 	return
 }
 
+// ReadFrom implements the interface io.ReaderFrom, which uses the ReadFrom
+// method of the underlying writer if it has implemented io.ReaderFrom,
+// such as the response writer of net/http, which will use the kernel
+// sendfile for *os.File. So io.Copy(r, file) keeps the zero-copy fast path.
+//
+// Notice: it does not use the fast path if the response filter is set.
+func (r *Response) ReadFrom(src io.Reader) (n int64, err error) {
+	r.WriteHeader(http.StatusOK)
+	if rf, ok := r.ResponseWriter.(io.ReaderFrom); ok && r.filter == nil {
+		n, err = rf.ReadFrom(src)
+		r.Size += n
+		return
+	}
+	return io.Copy(writerOnly{r}, src)
+}
+
+// writerOnly hides the method ReadFrom to avoid the recursion of io.Copy.
+type writerOnly struct{ io.Writer }
+
+// Written returns the number of the bytes of the response body
+// that have been written actually, which is equal to r.Size.
+func (r *Response) Written() int64 { return r.Size }
+
+// flushFiltered filters the buffered response body, then sends it
+// together with the response header.
+func (r *Response) flushFiltered() {
+	if r.filter == nil || !r.Wrote {
+		return
+	}
+
+	body := r.filter(r.buffer)
+	r.filter, r.buffer = nil, nil
+
+	header := r.ResponseWriter.Header()
+	if header.Get("Content-Length") != "" {
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+
+	r.writeHeader()
+	if len(body) > 0 {
+		n, _ := r.ResponseWriter.Write(body)
+		r.Size += int64(n)
+	}
+}
+
 // Reset resets the response to the initialized status.
 func (r *Response) Reset(w http.ResponseWriter) {
 	*r = Response{ResponseWriter: w, Status: http.StatusOK}
@@ -109,8 +222,38 @@ func (r *Response) Push(target string, opts *http.PushOptions) error {
 // buffered data to the client.
 //
 // See [http.Flusher](https://golang.org/pkg/net/http/#Flusher)
+//
+// Notice: it does nothing if the response filter is set.
 func (r *Response) Flush() {
+	if r.filter != nil {
+		return
+	}
 	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
 		flusher.Flush()
 	}
 }
+
+// headResponse is used to discard the response body of the HEAD request,
+// which sends the response header through once it is written.
+type headResponse struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (r *headResponse) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *headResponse) WriteHeader(code int) {
+	if !r.wrote {
+		r.wrote = true
+		r.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (r *headResponse) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return len(b), nil
+}
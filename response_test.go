@@ -0,0 +1,159 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestResponseSize(t *testing.T) {
+	rec := httptest.NewRecorder()
+	resp := NewResponse(rec)
+	resp.Write([]byte("abc"))
+	resp.WriteString("defg")
+	if n := resp.Written(); n != 7 {
+		t.Errorf("expect size %d, but got %d", 7, n)
+	}
+
+	resp.Reset(rec)
+	if n := resp.Written(); n != 0 {
+		t.Errorf("expect size %d, but got %d", 0, n)
+	}
+
+	f, err := ioutil.TempFile("", "ship")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("abcdefghij")
+	f.Close()
+
+	s := New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := s.AcquireContext(req, httptest.NewRecorder())
+	if err := c.File(f.Name()); err != nil {
+		t.Error(err)
+	} else if n := c.Response().Written(); n != 10 {
+		t.Errorf("expect size %d, but got %d", 10, n)
+	} else if code := c.StatusCode(); code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, code)
+	}
+	s.ReleaseContext(c)
+}
+
+func TestResponseBeforeAfter(t *testing.T) {
+	var calls []string
+	s := New()
+	s.Route("/").GET(func(c *Context) error {
+		c.Response().Before(func() {
+			calls = append(calls, "before1")
+			c.SetRespHeader("X-Before", "1")
+		})
+		c.Response().Before(func() { calls = append(calls, "before2") })
+		c.Response().After(func() { calls = append(calls, "after1") })
+		c.Response().After(func() { calls = append(calls, "after2") })
+		c.WriteHeader(http.StatusCreated)
+		c.WriteHeader(http.StatusOK)
+		return c.Text(http.StatusOK, "abc")
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expect status code %d, but got %d", http.StatusCreated, rec.Code)
+	}
+	if v := rec.Header().Get("X-Before"); v != "1" {
+		t.Errorf("expect header '%s', but got '%s'", "1", v)
+	}
+
+	expects := []string{"before1", "before2", "after1", "after2"}
+	if len(calls) != len(expects) {
+		t.Fatalf("expect calls %v, but got %v", expects, calls)
+	}
+	for i, call := range calls {
+		if call != expects[i] {
+			t.Errorf("%d: expect call '%s', but got '%s'", i, expects[i], call)
+		}
+	}
+}
+
+func TestResponseFilter(t *testing.T) {
+	s := New()
+	s.ResponseFilter = func(b []byte) []byte {
+		return []byte(strings.ToUpper(string(b)))
+	}
+	s.Route("/").GET(func(c *Context) error {
+		c.Response().Before(func() { c.SetRespHeader("X-Before", "1") })
+		c.SetRespHeader("Content-Length", "6")
+		c.WriteHeader(http.StatusAccepted)
+		c.Write([]byte("abc"))
+		c.Response().Flush()
+		_, err := c.Response().WriteString("def")
+		return err
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("expect status code %d, but got %d", http.StatusAccepted, rec.Code)
+	} else if rec.Flushed {
+		t.Errorf("unexpect the response to be flushed")
+	} else if body := rec.Body.String(); body != "ABCDEF" {
+		t.Errorf("expect response body '%s', but got '%s'", "ABCDEF", body)
+	} else if v := rec.Header().Get("X-Before"); v != "1" {
+		t.Errorf("expect header '%s', but got '%s'", "1", v)
+	}
+}
+
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom bool
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.readFrom = true
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+func TestResponseReadFrom(t *testing.T) {
+	rec := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	resp := NewResponse(rec)
+	if n, err := io.Copy(resp, io.LimitReader(strings.NewReader("abc"), 10)); err != nil {
+		t.Error(err)
+	} else if n != 3 || resp.Written() != 3 {
+		t.Errorf("expect size %d, but got %d and %d", 3, n, resp.Written())
+	} else if !rec.readFrom {
+		t.Errorf("expect to use ReadFrom of the underlying writer")
+	} else if !resp.Wrote || resp.Status != 200 {
+		t.Errorf("expect the status code %d to be written", 200)
+	}
+
+	resp = NewResponse(httptest.NewRecorder())
+	if n, err := io.Copy(resp, strings.NewReader("abcd")); err != nil {
+		t.Error(err)
+	} else if n != 4 || resp.Written() != 4 {
+		t.Errorf("expect size %d, but got %d and %d", 4, n, resp.Written())
+	}
+}
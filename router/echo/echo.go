@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -286,6 +287,8 @@ type node struct {
 	children []*node
 	handlers *methodHandler
 	parent   *node
+	prios    map[string]int // The priorities of the route, Method -> Priority
+	maxprio  int            // The maximum priority of the routes in the subtree
 }
 
 func newNode(t kind, name, prefix, ppath string, parent *node, children []*node,
@@ -316,6 +319,15 @@ func (n *node) Reset() {
 	*n.handlers = methodHandler{}
 }
 
+// priority returns the priority of the route of the node for the method,
+// which falls back to the priority set for all the methods.
+func (n *node) priority(method string) int {
+	if prio, ok := n.prios[method]; ok {
+		return prio
+	}
+	return n.prios[""]
+}
+
 func (n *node) AddChild(c *node) { n.children = append(n.children, c) }
 func (n *node) DelChild(c *node) {
 	for i, cn := range n.children {
@@ -389,6 +401,27 @@ type Config struct {
 	//
 	// Default: false.
 	RemoveTrailingSlash bool
+
+	// If true, the value captured by the wildcard parameter is prefixed
+	// with the leading slash, which is useful to proxy the request path.
+	//
+	// For example, for the route "/files/*path" and the request path
+	// "/files/a/b/c", the parameter "path" is "/a/b/c".
+	//
+	// Default: false.
+	WildcardLeadingSlash bool
+
+	// If true, the leading slash of the value captured by the wildcard
+	// parameter is stripped, which is useful as the filename.
+	//
+	// For example, for the route "/raw*path" and the request path
+	// "/raw/a/b/c", the parameter "path" is "a/b/c".
+	//
+	// If both WildcardLeadingSlash and WildcardStripLeadingSlash are false,
+	// the captured value is the rest of the path as it is.
+	//
+	// Default: false.
+	WildcardStripLeadingSlash bool
 }
 
 // Router is the registry of all registered routes to match the request
@@ -399,7 +432,10 @@ type Config struct {
 //
 // For the wildcard parameter, its starts with "*" followed by the optional
 // parameter name. If no the parameter name, it is "*" by default. such as
-// "/v1/path/to/*" or "/v1/path/to/*wildcard".
+// "/v1/path/to/*" or "/v1/path/to/*wildcard". The captured value contains
+// the rest of the path as it is, but the leading slash may be added
+// or stripped by the option Config.WildcardLeadingSlash or
+// Config.WildcardStripLeadingSlash.
 //
 // Moreover, the single and wildcard parameters may used in combination.
 // But the wildcard parameter must be the last.
@@ -421,8 +457,9 @@ type Router struct {
 	conf    Config
 	tree    *node
 	bufpool sync.Pool
-	maxnum  int               // The maximum number of the parameter
-	routes  map[string]string // Name -> Path
+	maxnum  int                       // The maximum number of the parameter
+	routes  map[string]string         // Name -> Path
+	prios   map[string]map[string]int // Path -> Method -> Priority
 }
 
 // NewRouter returns a new Router instance with the config.
@@ -503,6 +540,27 @@ func (r *Router) rangef(n *node, f func(string, string, string, interface{})) {
 	}
 }
 
+// Walk is the same as Range, but stops traversing when f returns false.
+func (r *Router) Walk(f func(name, path, method string, handler interface{}) bool) {
+	r.walkf(r.tree, f)
+}
+
+func (r *Router) walkf(n *node, f func(string, string, string, interface{}) bool) (ok bool) {
+	ok = true
+	if n.ppath != "" {
+		n.handlers.Range(func(method string, handler interface{}) {
+			if ok {
+				ok = f(n.name, n.ppath, method, handler)
+			}
+		})
+	}
+
+	for i, _len := 0, len(n.children); ok && i < _len; i++ {
+		ok = r.walkf(n.children[i], f)
+	}
+	return
+}
+
 /// ----------------------------------------------------------------------- ///
 
 // Add registers a new route for method and path with matching handler.
@@ -550,6 +608,7 @@ func (r *Router) Add(name, path, method string, h interface{}) (n int, err error
 	notend := true
 	pnames := []string{} // Param names
 	ppath := path        // Pristine path
+	var rn *node         // Route node
 
 	for i, l := 0, len(path); i < l; i++ {
 		if path[i] == ':' {
@@ -564,7 +623,7 @@ func (r *Router) Add(name, path, method string, h interface{}) (n int, err error
 			i, l = j, len(path)
 
 			if i == l {
-				r.insert(name, method, ppath, path[:i], pkind, h, pnames)
+				rn = r.insert(name, method, ppath, path[:i], pkind, h, pnames)
 				notend = false
 				break
 			} else {
@@ -577,24 +636,222 @@ func (r *Router) Add(name, path, method string, h interface{}) (n int, err error
 				name = "*"
 			}
 			pnames = append(pnames, name)
-			r.insert(name, method, ppath, path[:i+1], akind, h, pnames)
+			rn = r.insert(name, method, ppath, path[:i+1], akind, h, pnames)
 			notend = false
 			break
 		}
 	}
 
 	if notend {
-		r.insert(name, method, ppath, path, skind, h, pnames)
+		rn = r.insert(name, method, ppath, path, skind, h, pnames)
 	}
 
 	if addRoute {
 		r.routes[name] = ppath
 	}
+	if len(r.prios) > 0 {
+		// Only the nodes along the inserted path are changed.
+		r.updatePriorityPath(rn)
+	}
 	return r.maxnum, nil
 }
 
+// SetPriority sets the priority of the route with the path and method,
+// which has been registered or will be registered, and is used
+// as the tiebreaker to match the route. If method is empty, it is used
+// for all the methods without their own priorities.
+//
+// By default, the priority of all the routes is 0, and the matching order
+// is static > param > any. When some routes have the non-zero priority,
+// the route with the highest priority wins among all the routes matching
+// the path and method, and the order static > param > any is kept
+// for the routes with the same priority, for example,
+//
+//    router.Add("", "/posts/new", "GET", newHandler)
+//    router.Add("", "/posts/:slug", "GET", slugHandler)
+//    router.SetPriority("/posts/:slug", "GET", 1) // "GET /posts/new" matches ":slug".
+//
+// Notice: it should be used sparingly, because matching the route
+// with the priority is slower than without it.
+func (r *Router) SetPriority(path, method string, priority int) {
+	if r.conf.RemoveTrailingSlash {
+		path = strings.TrimRight(path, "/")
+	}
+	if path == "" {
+		path = "/"
+	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
+
+	method = strings.ToUpper(method)
+	first := len(r.prios) == 0
+	if priority == 0 {
+		if prios, ok := r.prios[path]; ok {
+			if delete(prios, method); len(prios) == 0 {
+				delete(r.prios, path)
+			}
+		}
+	} else {
+		if r.prios == nil {
+			r.prios = make(map[string]map[string]int, 4)
+		}
+		prios, ok := r.prios[path]
+		if !ok {
+			prios = make(map[string]int, 2)
+			r.prios[path] = prios
+		}
+		prios[method] = priority
+	}
+
+	if first {
+		// The children have not been sorted by the priority yet.
+		r.updatePriority(r.tree)
+	} else if cn := r.findNode(path); cn != nil && cn.ppath == path {
+		r.updatePriorityPath(cn)
+	}
+}
+
+// updatePriority updates the priorities of all the nodes in the subtree
+// of the node n.
+func (r *Router) updatePriority(n *node) {
+	for _, c := range n.children {
+		r.updatePriority(c)
+	}
+	r.updateNodePriority(n)
+}
+
+// updatePriorityPath updates the priorities of the node n and its ancestors.
+func (r *Router) updatePriorityPath(n *node) {
+	for ; n != nil; n = n.parent {
+		r.updateNodePriority(n)
+	}
+}
+
+// updateNodePriority updates the maximum priority of the subtree of the node n,
+// and sorts the children by the kind of them.
+func (r *Router) updateNodePriority(n *node) {
+	var has bool
+	var maxprio int
+	if n.ppath != "" {
+		n.prios = r.prios[n.ppath]
+		n.handlers.Range(func(method string, _ interface{}) {
+			if prio := n.priority(method); !has || prio > maxprio {
+				maxprio, has = prio, true
+			}
+		})
+	} else {
+		n.prios = nil
+	}
+
+	for _, c := range n.children {
+		if !has || c.maxprio > maxprio {
+			maxprio, has = c.maxprio, true
+		}
+	}
+
+	// Keep the matching order static > param > any as the tiebreaker
+	// for the routes with the same priority.
+	sort.SliceStable(n.children, func(i, j int) bool {
+		return n.children[i].kind < n.children[j].kind
+	})
+
+	n.maxprio = maxprio
+}
+
+// priorityMatcher is used to match the route with the priority.
+type priorityMatcher struct {
+	hasp    bool
+	method  string
+	anyMeth bool // If true, match the route of any method.
+	pvalues []string
+	bvalues []string // The parameter values of the best matched node
+	best    *node
+	prio    int
+}
+
+// Match matches the node n and its children with backtracking,
+// and records the matched node with the highest priority. For the same
+// priority, the first matched node in the order static > param > any wins.
+func (m *priorityMatcher) Match(n *node, search string, pi int) {
+	switch n.kind {
+	case skind:
+		if !strings.HasPrefix(search, n.prefix) {
+			return
+		}
+		search = search[len(n.prefix):]
+
+	case pkind:
+		if search == "" || (m.hasp && pi >= len(m.pvalues)) {
+			return
+		}
+
+		var i int
+		for _len := len(search); i < _len && search[i] != '/'; i++ {
+		}
+		if m.hasp {
+			m.pvalues[pi] = search[:i]
+		}
+		pi++
+		search = search[i:]
+
+	case akind:
+		if m.hasp {
+			m.pvalues[len(n.pnames)-1] = search
+		}
+		m.record(n, n)
+		return
+	}
+
+	if search == "" {
+		if !m.record(n, n) {
+			if c := n.FindChildByKind(akind); c != nil {
+				m.record(n, c)
+			}
+		}
+		return
+	}
+
+	for _, c := range n.children {
+		if m.best != nil && c.maxprio <= m.prio {
+			continue // No route in the subtree has the higher priority.
+		} else if c.kind != skind || c.label == search[0] {
+			m.Match(c, search, pi)
+		}
+	}
+}
+
+// record records the node n if the route of the node hn has the handler
+// for the method and has the higher priority than the recorded one,
+// and reports whether hn has the handler.
+func (m *priorityMatcher) record(n, hn *node) bool {
+	var prio int
+	if m.anyMeth {
+		if !hn.handlers.HasHandler() {
+			return false
+		}
+	} else if hn.handlers.FindHandler(m.method) == nil {
+		return false
+	} else {
+		prio = hn.priority(m.method)
+	}
+
+	if m.best == nil || prio > m.prio {
+		m.best, m.prio = n, prio
+		if m.hasp {
+			if m.bvalues == nil {
+				m.bvalues = make([]string, len(m.pvalues))
+			}
+			copy(m.bvalues, m.pvalues)
+		}
+	}
+	return true
+}
+
+// insert inserts the node with the prefix, and returns the node
+// containing the inserted route.
 func (r *Router) insert(name, method, ppath, prefix string,
-	t kind, h interface{}, pnames []string) {
+	t kind, h interface{}, pnames []string) *node {
 	// Adjust max param
 	l := len(pnames)
 	if r.maxnum < l {
@@ -603,6 +860,7 @@ func (r *Router) insert(name, method, ppath, prefix string,
 
 	cn := r.tree
 	search := prefix
+	var rn *node // The inserted child node
 
 	for {
 		sl := len(search)
@@ -636,6 +894,8 @@ func (r *Router) insert(name, method, ppath, prefix string,
 			// Split node
 			n := newNode(cn.kind, cn.name, cn.prefix[l:], cn.ppath, cn,
 				cn.children, cn.handlers, cn.pnames)
+			n.prios, n.maxprio = cn.prios, cn.maxprio
+			cn.prios = nil
 
 			// Reset parent node
 			cn.name = ""
@@ -661,6 +921,7 @@ func (r *Router) insert(name, method, ppath, prefix string,
 					newMethodHandler(), pnames)
 				n.handlers.AddHandler(method, h)
 				cn.AddChild(n)
+				rn = n
 			}
 
 		} else if l < sl {
@@ -679,6 +940,7 @@ func (r *Router) insert(name, method, ppath, prefix string,
 				newMethodHandler(), pnames)
 			n.handlers.AddHandler(method, h)
 			cn.AddChild(n)
+			rn = n
 
 		} else {
 
@@ -697,7 +959,10 @@ func (r *Router) insert(name, method, ppath, prefix string,
 
 		}
 
-		return
+		if rn == nil {
+			rn = cn
+		}
+		return rn
 	}
 }
 
@@ -725,6 +990,23 @@ func (r *Router) Match(path, method string, pnames, pvalues []string) (
 	)
 
 	method = strings.ToUpper(method)
+	if len(r.prios) > 0 {
+		m := priorityMatcher{hasp: hasp, method: method, pvalues: pvalues}
+		if m.Match(cn, search, 0); m.best == nil {
+			// Match the route of any method to respond MethodNotAllowed.
+			m.anyMeth = true
+			if m.Match(cn, search, 0); m.best == nil {
+				return r.conf.NotFoundHandler, 0 // Not found
+			}
+		}
+
+		cn = m.best
+		if hasp {
+			copy(pvalues, m.bvalues)
+		}
+		goto Found
+	}
+
 	// Search order static > param > any
 	for {
 		if search == "" {
@@ -835,6 +1117,7 @@ func (r *Router) Match(path, method string, pnames, pvalues []string) (
 		break
 	}
 
+Found:
 	if h = cn.handlers.FindHandler(method); h == nil { // NOTE: Slow zone...
 		// Dig further for any, might have an empty value for *,
 		// e.g. serving a directory. Issue #207.
@@ -848,15 +1131,36 @@ func (r *Router) Match(path, method string, pnames, pvalues []string) (
 			if pn = len(n.pnames); pn > 0 && hasp {
 				copy(pnames, n.pnames)
 				pvalues[pn-1] = ""
+				r.fixWildcard(n, pvalues[pn-1:pn])
 			}
 		}
 	} else if pn = len(cn.pnames); pn > 0 && hasp {
 		copy(pnames, cn.pnames)
+		r.fixWildcard(cn, pvalues[pn-1:pn])
 	}
 
 	return
 }
 
+// fixWildcard adds or strips the leading slash of the wildcard parameter
+// value by the option WildcardLeadingSlash or WildcardStripLeadingSlash.
+func (r *Router) fixWildcard(n *node, pvalue []string) {
+	if n.kind != akind {
+		return
+	}
+
+	value := pvalue[0]
+	if r.conf.WildcardLeadingSlash {
+		if value == "" || value[0] != '/' {
+			pvalue[0] = "/" + value
+		}
+	} else if r.conf.WildcardStripLeadingSlash {
+		if value != "" && value[0] == '/' {
+			pvalue[0] = value[1:]
+		}
+	}
+}
+
 /// ----------------------------------------------------------------------- ///
 
 // Del deletes the given route.
@@ -868,6 +1172,47 @@ func (r *Router) Del(path, method string) (err error) {
 }
 
 func (r *Router) delRoute(path, method string) (err error) {
+	// Delete the found node.
+	cn := r.findNode(path)
+	if cn != nil && len(r.prios) > 0 {
+		ppath := cn.ppath
+		r.removeNode(cn, method)
+		if prios, ok := r.prios[ppath]; ok {
+			if delete(prios, method); method == "" || len(prios) == 0 ||
+				!cn.handlers.HasHandler() {
+				delete(r.prios, ppath)
+			}
+		}
+
+		// The nodes may be merged or removed, so update the whole tree.
+		r.updatePriority(r.tree)
+		return
+	}
+
+	r.removeNode(cn, method)
+	return
+}
+
+// Methods returns the methods registered for the path, which may be
+// the route path with the parameters or the request path.
+//
+// Return nil if the path does not exist.
+func (r *Router) Methods(path string) []string {
+	cn := r.findNode(path)
+	if cn == nil || cn.ppath == "" {
+		return nil
+	}
+
+	methods := make([]string, 0, 4)
+	cn.handlers.Range(func(method string, _ interface{}) {
+		if method != "" {
+			methods = append(methods, method)
+		}
+	})
+	return methods
+}
+
+func (r *Router) findNode(path string) *node {
 	if r.conf.RemoveTrailingSlash {
 		// path = strings.TrimRight(path, "/")
 		path = removeTrailingSlash(path)
@@ -911,7 +1256,7 @@ func (r *Router) delRoute(path, method string) (err error) {
 			search = search[l:]
 		} else {
 			if nn == nil { // Issue #1348
-				return // Not found
+				return nil // Not found
 			}
 
 			cn = nn
@@ -978,15 +1323,13 @@ func (r *Router) delRoute(path, method string) (err error) {
 				}
 			}
 
-			return // Not found
+			return nil // Not found
 		}
 
 		break
 	}
 
-	// Delete the found node.
-	r.removeNode(cn, method)
-	return
+	return cn
 }
 
 func (r *Router) removeNode(cn *node, method string) {
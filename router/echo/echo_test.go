@@ -195,3 +195,184 @@ func TestRouterAnyMethod(t *testing.T) {
 		t.Error(rs)
 	}
 }
+
+func TestRouterMethods(t *testing.T) {
+	router := NewRouter(nil)
+	router.Add("", "/path", "GET", 1)
+	router.Add("", "/path", "POST", 2)
+	router.Add("", "/users/:id", "PUT", 3)
+
+	if methods := router.Methods("/path"); len(methods) != 2 ||
+		methods[0] != "GET" || methods[1] != "POST" {
+		t.Errorf("unexpected methods: %v", methods)
+	}
+
+	if methods := router.Methods("/users/123"); len(methods) != 1 || methods[0] != "PUT" {
+		t.Errorf("unexpected methods: %v", methods)
+	}
+
+	if methods := router.Methods("/users"); len(methods) != 0 {
+		t.Errorf("unexpected methods: %v", methods)
+	}
+}
+
+func TestRouterWildcardLeadingSlash(t *testing.T) {
+	tests := []struct {
+		path   string
+		pname  string
+		pvalue [3]string // Default, WildcardLeadingSlash, WildcardStripLeadingSlash
+	}{
+		{"/files/a/b/c", "path", [3]string{"a/b/c", "/a/b/c", "a/b/c"}},
+		{"/files/", "path", [3]string{"", "/", ""}},
+		{"/raw/a/b/c", "*", [3]string{"/a/b/c", "/a/b/c", "a/b/c"}},
+		{"/any/a/b/c", "*", [3]string{"a/b/c", "/a/b/c", "a/b/c"}},
+	}
+
+	confs := []Config{{}, {WildcardLeadingSlash: true}, {WildcardStripLeadingSlash: true}}
+	for i, conf := range confs {
+		router := NewRouter(&conf)
+		router.Add("", "/files/*path", "GET", "files")
+		router.Add("", "/raw*", "GET", "raw")
+		router.Add("", "/any/*", "GET", "any")
+
+		for _, test := range tests {
+			pnames, pvalues := make([]string, 1), make([]string, 1)
+			if h, n := router.Match(test.path, "GET", pnames, pvalues); h == nil {
+				t.Errorf("%d: no route handler for '%s'", i, test.path)
+			} else if n != 1 || pnames[0] != test.pname {
+				t.Errorf("%d %s: expect param name '%s', but got '%s'",
+					i, test.path, test.pname, pnames[0])
+			} else if pvalues[0] != test.pvalue[i] {
+				t.Errorf("%d %s: expect param value '%s', but got '%s'",
+					i, test.path, test.pvalue[i], pvalues[0])
+			}
+		}
+	}
+}
+
+func TestRouterPriority(t *testing.T) {
+	router := NewRouter(&Config{RemoveTrailingSlash: true})
+	router.Add("", "/posts/new", "GET", "new")
+	router.Add("", "/posts/newest", "GET", "newest")
+	router.Add("", "/posts/:slug", "GET", "slug")
+	router.Add("", "/posts/:slug/edit", "GET", "edit")
+	router.Add("", "/files/*", "GET", "files")
+
+	match := func(path string) (h interface{}, pvalue string) {
+		pnames, pvalues := make([]string, 1), make([]string, 1)
+		h, _ = router.Match(path, "GET", pnames, pvalues)
+		return h, pvalues[0]
+	}
+
+	tests := []struct {
+		path    string
+		handler interface{}
+		pvalue  string
+	}{
+		{"/posts/new", "slug", "new"},
+		{"/posts/newest", "slug", "newest"},
+		{"/posts/new/edit", "edit", "new"},
+		{"/posts/other", "slug", "other"},
+		{"/files/a/b", "files", "a/b"},
+		{"/none", nil, ""},
+	}
+
+	if h, _ := match("/posts/new"); h != "new" {
+		t.Errorf("expect the handler '%s', but got '%v'", "new", h)
+	}
+
+	router.SetPriority("/posts/:slug", "GET", 1)
+	router.SetPriority("/posts/:slug/edit", "", 1)
+	for _, test := range tests {
+		if h, v := match(test.path); h != test.handler {
+			t.Errorf("%s: expect the handler '%v', but got '%v'", test.path, test.handler, h)
+		} else if v != test.pvalue {
+			t.Errorf("%s: expect the param '%s', but got '%s'", test.path, test.pvalue, v)
+		}
+	}
+
+	// The static route with the negative priority is deprioritized.
+	router.SetPriority("/posts/:slug", "GET", 0)
+	router.SetPriority("/posts/:slug/edit", "", 0)
+	router.SetPriority("/posts/newest", "GET", -1)
+	if h, _ := match("/posts/newest"); h != "slug" {
+		t.Errorf("expect the handler '%s', but got '%v'", "slug", h)
+	} else if h, _ := match("/posts/new"); h != "new" {
+		t.Errorf("expect the handler '%s', but got '%v'", "new", h)
+	}
+
+	router.Del("/posts/newest", "")
+	if len(router.prios) != 0 {
+		t.Errorf("expect no priorities, but got %v", router.prios)
+	} else if h, _ := match("/posts/new"); h != "new" {
+		t.Errorf("expect the handler '%s', but got '%v'", "new", h)
+	}
+}
+
+func TestRouterPriorityMethod(t *testing.T) {
+	router := NewRouter(&Config{RemoveTrailingSlash: true})
+	router.Add("", "/posts/new", "GET", "new")
+	router.Add("", "/posts/new", "POST", "create")
+	router.Add("", "/posts/:slug", "GET", "slug")
+	router.Add("", "/posts/:slug", "PUT", "update")
+	router.SetPriority("/posts/:slug", "GET", 1)
+
+	match := func(method, path string) interface{} {
+		pnames, pvalues := make([]string, 1), make([]string, 1)
+		h, _ := router.Match(path, method, pnames, pvalues)
+		return h
+	}
+
+	tests := []struct {
+		method  string
+		path    string
+		handler interface{}
+	}{
+		{"GET", "/posts/new", "slug"},
+		{"POST", "/posts/new", "create"}, // ":slug" has no POST handler.
+		{"PUT", "/posts/new", "update"},  // "new" has no PUT handler.
+		{"PUT", "/posts/other", "update"},
+		{"POST", "/posts/other", nil},
+	}
+
+	for _, test := range tests {
+		if h := match(test.method, test.path); h != test.handler {
+			t.Errorf("%s %s: expect the handler '%v', but got '%v'",
+				test.method, test.path, test.handler, h)
+		}
+	}
+
+	// The priority is per route, so PUT keeps the default matching order.
+	router.Add("", "/posts/new", "PUT", "put new")
+	if h := match("PUT", "/posts/new"); h != "put new" {
+		t.Errorf("expect the handler '%s', but got '%v'", "put new", h)
+	} else if h := match("GET", "/posts/new"); h != "slug" {
+		t.Errorf("expect the handler '%s', but got '%v'", "slug", h)
+	}
+}
+
+func TestRouterWalk(t *testing.T) {
+	router := NewRouter(nil)
+	router.Add("", "/a", "GET", 1)
+	router.Add("", "/a", "POST", 2)
+	router.Add("", "/b", "GET", 3)
+	router.Add("", "/c/:id", "GET", 4)
+
+	var count int
+	router.Walk(func(name, path, method string, handler interface{}) bool {
+		count++
+		return true
+	})
+	if count != 4 {
+		t.Errorf("expect 4 routes, but got %d", count)
+	}
+
+	count = 0
+	router.Walk(func(name, path, method string, handler interface{}) bool {
+		count++
+		return handler != 2
+	})
+	if count != 2 {
+		t.Errorf("expect to stop after 2 routes, but got %d", count)
+	}
+}
@@ -14,19 +14,68 @@
 
 package router
 
-import "sync"
+import (
+	"net/http"
+	"sync"
+)
 
 // NewLockRouter returns a new lock Router based on the original router r.
-// So it's safe to access and modify the routes concurrently and safely.
+// So it's safe to access and modify the routes concurrently and safely,
+// for example, add and delete the routes while serving the requests.
 //
-// Notice: the wrapped router must not panic.
-func NewLockRouter(r Router) Router { return &lockRouter{router: r} }
+// Add, Del and SetPriority hold the write lock, and others hold the read lock.
+// So the request matches the route either before or after the modification,
+// never the half-modified routes.
+//
+// The returned router has also implemented the interfaces MethodsRouter,
+// WalkRouter and PriorityRouter, which are forwarded to r if r has
+// implemented them. Or, Methods matches the path with the standard methods
+// one by one, Walk falls back to Range, and SetPriority does nothing.
+//
+// isRoute is used by Methods to check whether the handler matched by
+// the method is a registered route, rather than the handler of NotFound
+// or MethodNotAllowed configured in r, for example,
+//
+//     NewLockRouter(r, func(h interface{}) bool { _, ok := h.(ship.Route); return ok })
+//
+// If not given, check whether the matched handler is not nil.
+//
+// Notice: the wrapped router must not panic, and the callback of Range
+// and Walk is called with the read lock held, so it must not add or delete
+// the routes, or it will deadlock.
+func NewLockRouter(r Router, isRoute ...func(handler interface{}) bool) Router {
+	lr := &lockRouter{router: r, isRoute: isNotNil}
+	if len(isRoute) > 0 && isRoute[0] != nil {
+		lr.isRoute = isRoute[0]
+	}
+	return lr
+}
 
-var _ Router = &lockRouter{}
+func isNotNil(handler interface{}) bool { return handler != nil }
+
+var (
+	_ Router         = &lockRouter{}
+	_ WalkRouter     = &lockRouter{}
+	_ MethodsRouter  = &lockRouter{}
+	_ PriorityRouter = &lockRouter{}
+)
+
+var standardMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
 
 type lockRouter struct {
-	lock   sync.RWMutex
-	router Router
+	lock    sync.RWMutex
+	router  Router
+	isRoute func(interface{}) bool
 }
 
 func (r *lockRouter) Range(f func(string, string, string, interface{})) {
@@ -35,6 +84,29 @@ func (r *lockRouter) Range(f func(string, string, string, interface{})) {
 	r.lock.RUnlock()
 }
 
+func (r *lockRouter) Walk(f func(string, string, string, interface{}) bool) {
+	r.lock.RLock()
+	if wr, ok := r.router.(WalkRouter); ok {
+		wr.Walk(f)
+	} else {
+		ok := true
+		r.router.Range(func(name, path, method string, handler interface{}) {
+			if ok {
+				ok = f(name, path, method, handler)
+			}
+		})
+	}
+	r.lock.RUnlock()
+}
+
+func (r *lockRouter) SetPriority(path, method string, priority int) {
+	if pr, ok := r.router.(PriorityRouter); ok {
+		r.lock.Lock()
+		pr.SetPriority(path, method, priority)
+		r.lock.Unlock()
+	}
+}
+
 func (r *lockRouter) Path(name string, params ...interface{}) string {
 	r.lock.RLock()
 	url := r.router.Path(name, params...)
@@ -42,6 +114,24 @@ func (r *lockRouter) Path(name string, params ...interface{}) string {
 	return url
 }
 
+func (r *lockRouter) Methods(path string) (methods []string) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if mr, ok := r.router.(MethodsRouter); ok {
+		return mr.Methods(path)
+	}
+
+	// Match the path with the standard methods one by one,
+	// which also contains the routes of any method.
+	for _, method := range standardMethods {
+		if h, _ := r.router.Match(path, method, nil, nil); r.isRoute(h) {
+			methods = append(methods, method)
+		}
+	}
+	return
+}
+
 func (r *lockRouter) Add(name, path, method string, handler interface{}) (
 	int, error) {
 	r.lock.Lock()
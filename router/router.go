@@ -15,6 +15,34 @@
 // Package router supplies a router interface ane some implementations.
 package router
 
+// MethodsRouter is an optional interface implemented by the router
+// to return the methods registered for the path.
+type MethodsRouter interface {
+	// Methods returns the methods registered for the path.
+	//
+	// Return nil if the path does not exist.
+	Methods(path string) []string
+}
+
+// WalkRouter is an optional interface implemented by the router
+// to traverse the registered routes lazily with the early termination.
+type WalkRouter interface {
+	// Walk traverses the registered routes, and stops when f returns false.
+	Walk(f func(name, path, method string, handler interface{}) bool)
+}
+
+// PriorityRouter is an optional interface implemented by the router
+// to set the priority of the route as the tiebreaker to match the route.
+type PriorityRouter interface {
+	// SetPriority sets the priority of the route with the path and method,
+	// and the route with the higher priority wins when more than one route
+	// match the request path and method. If method is empty, it is used
+	// for all the methods.
+	//
+	// The default priority is 0.
+	SetPriority(path, method string, priority int)
+}
+
 // Router is a router manager based on the path with the optional method.
 type Router interface {
 	// Range traverses all the registered routes.
@@ -18,10 +18,13 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"io"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -53,6 +56,29 @@ func (r *OnceRunner) Run() {
 	}
 }
 
+// AutoTLSManager is used to obtain and renew the TLS certificates
+// automatically, such as *autocert.Manager of the package
+// "golang.org/x/crypto/acme/autocert".
+type AutoTLSManager interface {
+	GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	HTTPHandler(fallback http.Handler) http.Handler
+}
+
+// NewAutoTLSManager is used to new an AutoTLSManager, which caches
+// the certificates into cacheDir and only obtains them for the given hosts.
+//
+// Default: nil, so you must set it before calling Runner.StartAutoTLS,
+// so that ship does not depend on the ACME implementation. For example,
+//
+//    ship.NewAutoTLSManager = func(cacheDir string, hosts ...string) ship.AutoTLSManager {
+//        return &autocert.Manager{
+//            Prompt:     autocert.AcceptTOS,
+//            Cache:      autocert.DirCache(cacheDir),
+//            HostPolicy: autocert.HostWhitelist(hosts...),
+//        }
+//    }
+var NewAutoTLSManager func(cacheDir string, hosts ...string) AutoTLSManager
+
 // Runner is a HTTP Server runner.
 type Runner struct {
 	Name      string
@@ -61,8 +87,32 @@ type Runner struct {
 	Signals   []os.Signal
 	ConnState func(net.Conn, http.ConnState)
 
+	// AutoTLSCacheDir is the directory to cache the certificates
+	// obtained by StartAutoTLS.
+	//
+	// Default: "autocert"
+	AutoTLSCacheDir string
+
+	// AutoTLSHTTPAddr is the address of the HTTP server to serve
+	// the ACME HTTP-01 challenge, which is started by StartAutoTLS.
+	//
+	// Default: ":80"
+	AutoTLSHTTPAddr string
+
+	// ShutdownDelay is the delay to wait for after the readiness check
+	// starts to fail and before shutting down the server, so that
+	// the load balancer has time to notice it and drain the server.
+	// The delay is ended early if the context of Shutdown is done.
+	//
+	// Default: 0
+	ShutdownDelay time.Duration
+
 	err    error
 	done   chan struct{}
+	closed int32
+	addr   atomic.Value
+	lock   sync.RWMutex
+	certs  []hostcert
 	shut   *OnceRunner
 	stop   *OnceRunner
 	stopfs []*OnceRunner
@@ -129,6 +179,68 @@ func (r *Runner) SetLogger(logger Logger) *Runner {
 	return r
 }
 
+// WithHealthChecks wraps the handler of the server to serve the liveness
+// and readiness checks on the paths "/healthz" and "/readyz",
+// which respond with 200 if the check returns nil, or 503 with the error
+// message. If live or ready is nil, it is regarded as always passing.
+//
+// The readiness check fails automatically once the runner starts to
+// shut down, so that the load balancer can drain the server,
+// which should be used with ShutdownDelay.
+//
+// Notice: it should be called after setting the handler and before starting.
+func (r *Runner) WithHealthChecks(live, ready func() error) *Runner {
+	if r.Server.Handler == nil {
+		panic("Runner: Server.Handler is nil")
+	}
+
+	r.Server.Handler = healthHandler{
+		runner:  r,
+		live:    live,
+		ready:   ready,
+		handler: r.Server.Handler,
+	}
+	return r
+}
+
+type healthHandler struct {
+	runner  *Runner
+	live    func() error
+	ready   func() error
+	handler http.Handler
+}
+
+func (h healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/healthz":
+		h.serveCheck(w, h.live)
+	case "/readyz":
+		if atomic.LoadInt32(&h.runner.closed) == 1 {
+			h.serveCheck(w, func() error { return errors.New("shutting down") })
+		} else {
+			h.serveCheck(w, h.ready)
+		}
+	default:
+		h.handler.ServeHTTP(w, r)
+	}
+}
+
+func (h healthHandler) serveCheck(w http.ResponseWriter, check func() error) {
+	var err error
+	if check != nil {
+		err = check()
+	}
+
+	w.Header().Set(HeaderContentType, MIMETextPlainCharsetUTF8)
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, err.Error())
+	} else {
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "ok")
+	}
+}
+
 // RegisterOnShutdown registers some shutdown functions to run
 // when the http server is shut down.
 func (r *Runner) RegisterOnShutdown(functions ...func()) {
@@ -137,8 +249,60 @@ func (r *Runner) RegisterOnShutdown(functions ...func()) {
 	}
 }
 
+// Addr returns the address of the listener, which is useful to get
+// the actual port when listening on ":0".
+//
+// Return nil if the server has not started to listen.
+func (r *Runner) Addr() net.Addr {
+	if addr, ok := r.addr.Load().(net.Addr); ok {
+		return addr
+	}
+	return nil
+}
+
+// AddCertificate adds the certificate loaded from certFile and keyFile
+// for the hosts matching hostPattern, which is selected by the SNI server name
+// during the TLS handshake and reloaded automatically when the files change.
+//
+// hostPattern is either an exact host, such as "www.example.com",
+// or a wildcard host, such as "*.example.com", which only matches
+// one level of subdomain. If no pattern matches the server name,
+// the certificate given by Start is used as the default.
+//
+// If any certificate is added, the server is started with TLS
+// even if Start is not given the cert and key files.
+func (r *Runner) AddCertificate(hostPattern, certFile, keyFile string) (err error) {
+	if hostPattern == "" {
+		panic("Runner: the host pattern must not be empty")
+	}
+
+	cert := &tlscert{runner: r, certFile: certFile, keyFile: keyFile}
+	if _, err = cert.updateCert(); err != nil {
+		return
+	}
+	go cert.WatchCertFile()
+
+	r.lock.Lock()
+	r.certs = append(r.certs, hostcert{strings.ToLower(hostPattern), cert})
+	r.lock.Unlock()
+	return
+}
+
 // Shutdown stops the HTTP server.
+//
+// If ShutdownDelay is greater than 0, it waits for the delay after failing
+// the readiness check and before shutting down the server.
 func (r *Runner) Shutdown(ctx context.Context) (err error) {
+	atomic.StoreInt32(&r.closed, 1)
+	if r.ShutdownDelay > 0 {
+		timer := time.NewTimer(r.ShutdownDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
 	err = r.Server.Shutdown(ctx)
 	r.stop.Run()
 	return
@@ -149,7 +313,6 @@ func (r *Runner) Stop()        { r.shut.Run() }
 func (r *Runner) runShutdown() { r.Shutdown(context.Background()) }
 func (r *Runner) runStopfs() {
 	defer close(r.done)
-	r.logShutdown()
 	for i := len(r.stopfs) - 1; i >= 0; i-- {
 		r.stopfs[i].Run()
 	}
@@ -174,6 +337,60 @@ func (r *Runner) Start(addr string, tlsFiles ...string) {
 	r.startServer(cert, key)
 }
 
+// StartAutoTLS starts a HTTPS server with addr until it is closed,
+// which obtains and renews the certificates for the given hosts
+// automatically by NewAutoTLSManager, such as Let's Encrypt.
+//
+// It also starts a HTTP server on AutoTLSHTTPAddr to serve the ACME
+// HTTP-01 challenge, which redirects other requests to HTTPS,
+// and the two servers are shut down together.
+//
+// Prerequisites:
+//   - The DNS records of all the hosts must resolve to this server.
+//   - The ports of addr and AutoTLSHTTPAddr, that's, 443 and 80 by default,
+//     must be reachable from the internet, which is required by the ACME CA.
+//   - AutoTLSCacheDir must be persistent and writable, or the certificates
+//     will be obtained again on each restart and may hit the rate limits.
+func (r *Runner) StartAutoTLS(addr string, hosts ...string) {
+	if NewAutoTLSManager == nil {
+		panic("Runner: NewAutoTLSManager is not set")
+	} else if len(hosts) == 0 {
+		panic("Runner: no hosts for auto tls")
+	}
+
+	cacheDir := r.AutoTLSCacheDir
+	if cacheDir == "" {
+		cacheDir = "autocert"
+	}
+
+	httpAddr := r.AutoTLSHTTPAddr
+	if httpAddr == "" {
+		httpAddr = ":80"
+	}
+
+	manager := NewAutoTLSManager(cacheDir, hosts...)
+	if r.Server.TLSConfig == nil {
+		r.Server.TLSConfig = &tls.Config{}
+	}
+	r.Server.TLSConfig.GetCertificate = manager.GetCertificate
+	if len(r.Server.TLSConfig.NextProtos) == 0 {
+		r.Server.TLSConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+
+	challenge := NewRunner(manager.HTTPHandler(nil))
+	challenge.Name = r.Name
+	challenge.Logger = r.Logger
+	challenge.Signals = nil
+	challenge.Server.Addr = httpAddr
+	challenge.Link(r)
+	go challenge.Start("")
+
+	if addr == "" && r.Server.Addr == "" {
+		addr = ":443"
+	}
+	r.Start(addr)
+}
+
 func (r *Runner) startServer(certFile, keyFile string) {
 	if r.Server.Addr == "" {
 		panic("Runner: Server.Addr is empty")
@@ -187,7 +404,11 @@ func (r *Runner) startServer(certFile, keyFile string) {
 		r.infof("The HTTP Server [%s] is running on %s", r.Name, r.Server.Addr)
 	}
 
-	if certFile != "" && keyFile != "" {
+	r.lock.RLock()
+	hasHostCerts := len(r.certs) > 0
+	r.lock.RUnlock()
+
+	if (certFile != "" && keyFile != "") || hasHostCerts {
 		if r.Server.TLSConfig == nil {
 			r.Server.TLSConfig = &tls.Config{GetCertificate: r.getCertificate(certFile, keyFile)}
 		} else if r.Server.TLSConfig.GetCertificate == nil {
@@ -196,14 +417,20 @@ func (r *Runner) startServer(certFile, keyFile string) {
 	}
 
 	go r.handleSignals(r.done)
-	if r.Server.TLSConfig != nil {
-		r.err = r.Server.ListenAndServeTLS(certFile, keyFile)
+	if ln, err := net.Listen("tcp", r.Server.Addr); err != nil {
+		r.err = err
 	} else {
-		r.err = r.Server.ListenAndServe()
+		r.addr.Store(ln.Addr())
+		if r.Server.TLSConfig != nil {
+			r.err = r.Server.ServeTLS(ln, certFile, keyFile)
+		} else {
+			r.err = r.Server.Serve(ln)
+		}
 	}
 
 	r.Stop()
 	<-r.done
+	r.logShutdown()
 }
 
 func (r *Runner) logShutdown() {
@@ -253,17 +480,60 @@ func (r *Runner) handleSignals(exit <-chan struct{}) {
 }
 
 func (r *Runner) getCertificate(certFile, keyFile string) getCertificate {
-	cert := tlscert{runner: r, certFile: certFile, keyFile: keyFile}
-	if _, err := cert.updateCert(); err != nil {
-		r.errorf("fail to load certificate: cert=%s, key=%s, err=%v",
-			certFile, keyFile, err)
+	var defaultCert getCertificate
+	if certFile != "" && keyFile != "" {
+		cert := &tlscert{runner: r, certFile: certFile, keyFile: keyFile}
+		if _, err := cert.updateCert(); err != nil {
+			r.errorf("fail to load certificate: cert=%s, key=%s, err=%v",
+				certFile, keyFile, err)
+		}
+		go cert.WatchCertFile()
+		defaultCert = cert.GetCertificate
+	}
+
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if cert := r.getHostCertificate(hello.ServerName); cert != nil {
+			return cert.GetCertificate(hello)
+		} else if defaultCert != nil {
+			return defaultCert(hello)
+		}
+		return nil, errors.New("missing the certificate")
 	}
-	go cert.WatchCertFile()
-	return cert.GetCertificate
+}
+
+func (r *Runner) getHostCertificate(serverName string) *tlscert {
+	if serverName == "" {
+		return nil
+	}
+
+	serverName = strings.ToLower(strings.TrimSuffix(serverName, "."))
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for _, hc := range r.certs {
+		if matchHostPattern(hc.pattern, serverName) {
+			return hc.cert
+		}
+	}
+	return nil
+}
+
+func matchHostPattern(pattern, host string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		suffix := pattern[1:]
+		return strings.HasSuffix(host, suffix) &&
+			strings.IndexByte(host[:len(host)-len(suffix)], '.') < 0 &&
+			len(host) > len(suffix)
+	}
+	return pattern == host
 }
 
 type getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
 
+type hostcert struct {
+	pattern string
+	cert    *tlscert
+}
+
 type tlscert struct {
 	runner   *Runner
 	certFile string
@@ -0,0 +1,185 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCert(t *testing.T, dir, host string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, host+".crt")
+	keyFile = filepath.Join(dir, host+".key")
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err = ioutil.WriteFile(certFile, certPem, 0600); err != nil {
+		t.Fatal(err)
+	} else if err = ioutil.WriteFile(keyFile, keyPem, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestRunnerAddCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ship_runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := NewRunner(New())
+	defer close(r.done)
+
+	defaultCert, defaultKey := writeTestCert(t, dir, "default")
+	for _, host := range []string{"www.example.com", "*.example.org"} {
+		certFile, keyFile := writeTestCert(t, dir, host)
+		if err := r.AddCertificate(host, certFile, keyFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	getCert := r.getCertificate(defaultCert, defaultKey)
+	for serverName, expect := range map[string]string{
+		"www.example.com": "www.example.com",
+		"WWW.Example.COM": "www.example.com",
+		"api.example.org": "*.example.org",
+		"a.b.example.org": "default",
+		"example.org":     "default",
+		"":                "default",
+	} {
+		cert, err := getCert(&tls.ClientHelloInfo{ServerName: serverName})
+		if err != nil {
+			t.Errorf("%s: %s", serverName, err)
+			continue
+		}
+
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		} else if leaf.Subject.CommonName != expect {
+			t.Errorf("%s: expect the certificate '%s', but got '%s'",
+				serverName, expect, leaf.Subject.CommonName)
+		}
+	}
+}
+
+func TestRunnerWithHealthChecks(t *testing.T) {
+	var notReady error
+	s := New()
+	s.Route("/").GET(OkHandler())
+	r := NewRunner(s).WithHealthChecks(nil, func() error { return notReady })
+
+	check := func(path string, code int, body string) {
+		rec := httptest.NewRecorder()
+		r.Server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != code {
+			t.Errorf("%s: expect status code %d, but got %d", path, code, rec.Code)
+		} else if body != "" && rec.Body.String() != body {
+			t.Errorf("%s: expect body '%s', but got '%s'", path, body, rec.Body.String())
+		}
+	}
+
+	check("/", 200, "")
+	check("/healthz", 200, "ok")
+	check("/readyz", 200, "ok")
+
+	notReady = errors.New("not ready")
+	check("/readyz", 503, "not ready")
+
+	notReady = nil
+	r.ShutdownDelay = time.Millisecond * 50
+	done := make(chan struct{})
+	start := time.Now()
+	go func() { r.Shutdown(context.Background()); close(done) }()
+
+	time.Sleep(time.Millisecond * 10)
+	check("/", 200, "")
+	check("/healthz", 200, "ok")
+	check("/readyz", 503, "shutting down")
+
+	<-done
+	if elapsed := time.Since(start); elapsed < r.ShutdownDelay {
+		t.Errorf("expect to shut down after %s, but got %s", r.ShutdownDelay, elapsed)
+	}
+}
+
+func TestRunnerAddr(t *testing.T) {
+	r := NewRunner(New().Route("/").GET(OkHandler()).Ship())
+	r.Signals = nil
+	if addr := r.Addr(); addr != nil {
+		t.Errorf("unexpected address '%s' before starting", addr)
+	}
+
+	stopped := make(chan struct{})
+	go func() { r.Start("127.0.0.1:0"); close(stopped) }()
+
+	var addr net.Addr
+	for i := 0; i < 100 && addr == nil; i++ {
+		time.Sleep(time.Millisecond * 10)
+		addr = r.Addr()
+	}
+	if addr == nil {
+		t.Fatal("the runner has not started to listen")
+	}
+
+	resp, err := http.Get("http://" + addr.String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, resp.StatusCode)
+	}
+
+	r.Stop()
+	<-stopped
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+// SchemaValidator is used to validate the raw data, such as the request body,
+// by the schema.
+type SchemaValidator interface {
+	// Validate validates the data and returns the violations as the error.
+	Validate(data []byte) error
+}
+
+// SchemaValidatorFunc is the function type implementing the interface
+// SchemaValidator.
+type SchemaValidatorFunc func(data []byte) error
+
+// Validate implements the interface SchemaValidator.
+func (f SchemaValidatorFunc) Validate(data []byte) error { return f(data) }
+
+// SchemaCompiler is used to compile the schema, such as JSON Schema,
+// to a SchemaValidator, which may be implemented by any schema library.
+type SchemaCompiler interface {
+	Compile(schema []byte) (SchemaValidator, error)
+}
+
+// SchemaCompilerFunc is the function type implementing the interface
+// SchemaCompiler.
+type SchemaCompilerFunc func(schema []byte) (SchemaValidator, error)
+
+// Compile implements the interface SchemaCompiler.
+func (f SchemaCompilerFunc) Compile(schema []byte) (SchemaValidator, error) {
+	return f(schema)
+}
+
+// ValidateBody returns a middleware to validate the request body by v
+// before executing the handler, which returns ErrUnprocessableEntity
+// with the violations if failing to validate it.
+//
+// Notice: the whole request body is read into the memory by Context.RawBody,
+// which is limited by Context.MaxBodySize, so that the handler can read it
+// again.
+func ValidateBody(v SchemaValidator) Middleware {
+	return func(next Handler) Handler {
+		return func(c *Context) error {
+			if c.Request().Body == nil {
+				return next(c)
+			}
+
+			data, err := c.RawBody()
+			switch err {
+			case nil:
+			case ErrStatusRequestEntityTooLarge:
+				return err
+			default:
+				return ErrBadRequest.New(err)
+			}
+
+			if err = v.Validate(data); err != nil {
+				return ErrUnprocessableEntity.New(err)
+			}
+
+			return next(c)
+		}
+	}
+}
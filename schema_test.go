@@ -0,0 +1,63 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouteBuilderValidateBody(t *testing.T) {
+	router := New()
+	router.SchemaCompiler = SchemaCompilerFunc(func(schema []byte) (SchemaValidator, error) {
+		return SchemaValidatorFunc(func(data []byte) error {
+			if !bytes.Contains(data, schema) {
+				return errors.New("missing the field 'name'")
+			}
+			return nil
+		}), nil
+	})
+
+	router.Route("/path").ValidateBody([]byte(`"name"`)).POST(func(c *Context) error {
+		data, err := ioutil.ReadAll(c.Body())
+		if err != nil {
+			return err
+		}
+		return c.Blob(200, MIMEApplicationJSON, data)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/path", strings.NewReader(`{"name":"xgfone"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, rec.Code)
+	} else if body := rec.Body.String(); body != `{"name":"xgfone"}` {
+		t.Errorf("unexpected body '%s'", body)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/path", strings.NewReader(`{"age":18}`))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expect status code %d, but got %d", http.StatusUnprocessableEntity, rec.Code)
+	} else if body := rec.Body.String(); body != "missing the field 'name'" {
+		t.Errorf("unexpected body '%s'", body)
+	}
+}
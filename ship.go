@@ -16,10 +16,14 @@ package ship
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"net/url"
 	"os"
+	"reflect"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/xgfone/ship/v5/router"
 	"github.com/xgfone/ship/v5/router/echo"
@@ -45,6 +49,28 @@ type Ship struct {
 	// Default: ""
 	Prefix string
 
+	// BaseURL is the externally visible base url, such as
+	// "https://www.example.com", which is used by Context.AbsoluteURL
+	// when the request host is not the one visible for the client,
+	// for example, behind a load balancer.
+	//
+	// Default: ""
+	BaseURL string
+
+	// If true, clean the request path by CleanPath before matching the route,
+	// which collapses the multiple slashes and resolves "." and "..",
+	// to guard against the path traversal. But the original path of the request
+	// is kept unchanged for the handlers.
+	//
+	// Default: false
+	CleanPath bool
+
+	// If greater than 0, the request whose url path is longer than it
+	// is rejected with the status code 414 before routing.
+	//
+	// Default: 0, which is unlimited
+	MaxPathLength int
+
 	// The initialization capacity of Context.Data.
 	//
 	// Default: 0
@@ -60,6 +86,41 @@ type Ship struct {
 	// Default: 256
 	MiddlewareMaxNum int
 
+	// If true, the HEAD request is handled by the GET handler of the route
+	// if the route has no HEAD handler, and the response body is discarded
+	// but the response headers are sent through once they are written,
+	// which contain "Content-Length" only if the GET handler sets it.
+	//
+	// Default: false
+	AutoHEAD bool
+
+	// If true, the OPTIONS request is responded with the status code 204
+	// and the header "Allow" containing the methods registered for the path
+	// if the route has no OPTIONS handler. For "OPTIONS *", the header "Allow"
+	// contains all the methods registered for any path.
+	//
+	// Default: false
+	AutoOptions bool
+
+	// If true, log the composed middleware chain of each route
+	// when registering it, which is useful to debug the middleware order.
+	//
+	// Default: false
+	Debug bool
+
+	// If true, the Context is not put back into the pool after handling
+	// the request, but zeroed more aggressively, such as the Data map,
+	// the url parameters and the cached query, so that the handler or
+	// goroutine holding the Context or its Data by mistake cannot see
+	// the data of other requests, which helps diagnose the cross-request
+	// data bleed.
+	//
+	// Notice: it allocates a new Context for each request, which is slower
+	// and produces more garbage. So it should be only used to debug.
+	//
+	// Default: false
+	StrictContextReset bool
+
 	// Router is the route manager to manage all the routes.
 	//
 	// Default: echo.NewRouter(&echo.Config{RemoveTrailingSlash: true})
@@ -67,9 +128,20 @@ type Ship struct {
 
 	// The default handler when not finding the route.
 	//
-	// Default: NotFoundHandler()
+	// Default: respond {"error":"not found"} as JSON for the client accepting
+	// JSON, the html page for the client preferring HTML, or the plain text
+	// "Not Found" for others, with the status code 404.
 	NotFound Handler
 
+	// Fallback is the final fallback handler when not finding the route,
+	// which is called before NotFound, such as trying the legacy router
+	// to migrate from another routing system gradually.
+	//
+	// If it returns ErrNotFound without responding, NotFound is called.
+	//
+	// Default: nil
+	Fallback Handler
+
 	// Filter the route if returning true when registering and unregistering it.
 	//
 	// Default: nil
@@ -80,10 +152,28 @@ type Ship struct {
 	// Default: nil
 	RouteModifier func(Route) Route
 
+	// SchemaCompiler is used to compile the schema of the request body
+	// by RouteBuilder.ValidateBody.
+	//
+	// Default: nil
+	SchemaCompiler SchemaCompiler
+
+	// ResponseFilter is used to filter the response body before sending it,
+	// such as minifying the html or redacting the sensitive data.
+	//
+	// Notice: if set, the whole response body is buffered in memory and sent
+	// together with the response header only when finishing the request,
+	// because the streaming body cannot be filtered incrementally.
+	// So the response is no longer streamed, and Flush does nothing.
+	//
+	// Default: nil
+	ResponseFilter func([]byte) []byte
+
 	// HandleError is used to handle the error at last
 	// if the handler or middleware returns an error.
 	//
-	// Default: respond the error to the client if not responding.
+	// Default: respond the error to the client if not responding,
+	// but do nothing except logging if the client has disconnected.
 	HandleError func(c *Context, err error)
 
 	// Context Settings.
@@ -94,10 +184,69 @@ type Ship struct {
 	Validator Validator                                   // Default: nil
 	Defaulter Defaulter                                   // Default: SetStructFieldToDefault
 	BindQuery func(dst interface{}, src url.Values) error // Default: BindURLValues(dst, src, "query")
-	Responder func(c *Context, args ...interface{}) error // Default: nil
+	Responder func(c *Context, args ...interface{}) error // Default: DefaultResponder
+
+	// QueryParser is used to parse the raw query of the request url
+	// by Context.Query and Context.Queries, such as ParseQueryWithSemicolon
+	// and ParseQueryWithBrackets.
+	//
+	// Notice: the custom parser should be careful to keep the same semantics
+	// as the proxies or caches in front of the server, because the request
+	// smuggling or cache poisoning may happen if they parse the query
+	// in the different ways, which is why the semicolon separator is not
+	// supported by the standard library since Go 1.17.
+	//
+	// Default: nil, which uses http.Request.URL.Query()
+	QueryParser func(rawQuery string) url.Values
+
+	// MessageBundle is used by Context.T to translate the messages.
+	//
+	// Default: nil
+	MessageBundle MessageBundle
+
+	// CookieDefaults is the default attributes of the cookies
+	// set by Context.SetCookie, such as Path, Domain, MaxAge, SameSite,
+	// Secure and HttpOnly, which are only applied to the cookie fields
+	// that are unset. So the explicit cookie fields win.
+	//
+	// Notice: since the unset bool field cannot be distinguished from false,
+	// Secure and HttpOnly of the cookie are always true if they are true
+	// in CookieDefaults.
+	//
+	// Default: nil
+	CookieDefaults *http.Cookie
+
+	// OnRouteMatched is called after matching the route, with whether
+	// a route is matched and how long matching the route took,
+	// which is used to diagnose the performance of the routing phase.
+	//
+	// Default: nil
+	OnRouteMatched func(c *Context, matched bool, dur time.Duration)
+
+	// IndexFiles is the list of the index file names, which are tried
+	// in turn when serving a directory by Context.File and the static routes,
+	// such as Static, StaticFS and StaticWithListing.
+	//
+	// Default: []string{"index.html"}
+	IndexFiles []string
+
+	// MaxBodySize is the maximum size of the request body read into
+	// the memory or copied by Context.RawBody and Context.CopyBody,
+	// which are also used by the middlewares such as ValidateBody.
+	// If the body exceeds it, return ErrStatusRequestEntityTooLarge.
+	//
+	// If it is equal to or less than 0, it is unlimited.
+	//
+	// Default: DefaultMaxBodySize
+	MaxBodySize int64
 
 	mws     []Middleware
 	pmws    []Middleware
+	pages   map[int]func(*Context, error) error
+	errmap  *errorMapping
+	guards  map[string]*routeGuards
+	cvalues []contextValue
+	errfns  []func(*Context, error) error
 	handler Handler
 	cpool   sync.Pool
 	bpool   sync.Pool
@@ -110,13 +259,19 @@ func New() *Ship {
 		Router:      echo.NewRouter(&echo.Config{RemoveTrailingSlash: true}),
 		Logger:      NewLoggerFromWriter(os.Stderr, ""),
 		Session:     NewMemorySession(),
-		NotFound:    NotFoundHandler(),
+		NotFound:    contentNegotiatingNotFound(),
 		HandleError: handleErrorDefault,
 		Defaulter:   DefaulterFunc(SetStructFieldToDefault),
 		BindQuery:   bindQuery,
+		Responder:   DefaultResponder,
+		IndexFiles:  []string{"index.html"},
+		MaxBodySize: DefaultMaxBodySize,
 
 		URLParamMaxNum:   4,
 		MiddlewareMaxNum: 256,
+
+		pages:  make(map[int]func(*Context, error) error),
+		errmap: new(errorMapping),
 	}
 
 	s.handler = s.handleRequest
@@ -134,6 +289,8 @@ func New() *Ship {
 func Default() *Ship {
 	mb := NewMuxBinder()
 	mb.Add(MIMEApplicationJSON, JSONBinder())
+	mb.Add(MIMEApplicationNDJSON, NDJSONBinder())
+	mb.Add(MIMEApplicationMergePatch, JSONBinder())
 	mb.Add(MIMETextXML, XMLBinder())
 	mb.Add(MIMEApplicationXML, XMLBinder())
 	mb.Add(MIMEMultipartForm, FormBinder(MaxMemoryLimit))
@@ -166,38 +323,95 @@ func (s *Ship) Clone(name string, router Router) *Ship {
 
 		// Public
 		Prefix:           s.Prefix,
+		BaseURL:          s.BaseURL,
+		CleanPath:        s.CleanPath,
+		MaxPathLength:    s.MaxPathLength,
+		AutoHEAD:         s.AutoHEAD,
+		AutoOptions:      s.AutoOptions,
+		Debug:            s.Debug,
 		NotFound:         s.NotFound,
+		Fallback:         s.Fallback,
 		HandleError:      s.HandleError,
 		RouteFilter:      s.RouteFilter,
 		RouteModifier:    s.RouteModifier,
+		SchemaCompiler:   s.SchemaCompiler,
+		ResponseFilter:   s.ResponseFilter,
 		CtxDataInitCap:   s.CtxDataInitCap,
 		URLParamMaxNum:   s.URLParamMaxNum,
 		MiddlewareMaxNum: s.MiddlewareMaxNum,
 
+		StrictContextReset: s.StrictContextReset,
+
 		// Context
-		Binder:    s.Binder,
-		Logger:    s.Logger,
-		Session:   s.Session,
-		Renderer:  s.Renderer,
-		BindQuery: s.BindQuery,
-		Validator: s.Validator,
-		Responder: s.Responder,
-		Defaulter: s.Defaulter,
+		Binder:        s.Binder,
+		Logger:        s.Logger,
+		Session:       s.Session,
+		Renderer:      s.Renderer,
+		BindQuery:     s.BindQuery,
+		QueryParser:   s.QueryParser,
+		Validator:     s.Validator,
+		Responder:     s.Responder,
+		Defaulter:     s.Defaulter,
+		MessageBundle: s.MessageBundle,
+
+		CookieDefaults: s.CookieDefaults,
+		OnRouteMatched: s.OnRouteMatched,
+		IndexFiles:     append([]string(nil), s.IndexFiles...),
+		MaxBodySize:    s.MaxBodySize,
 	}
 
 	// Private
 	newShip.handler = newShip.handleRequest
+	newShip.pages = make(map[int]func(*Context, error) error, len(s.pages))
+	for status, render := range s.pages {
+		newShip.pages[status] = render
+	}
+
+	newShip.errmap = new(errorMapping)
+	if s.errmap != nil {
+		newShip.errmap.errs = append(newShip.errmap.errs, s.errmap.errs...)
+		newShip.errmap.types = append(newShip.errmap.types, s.errmap.types...)
+	}
+
 	newShip.cpool.New = func() interface{} { return newShip.NewContext() }
 	newShip.bpool.New = func() interface{} {
 		return bytes.NewBuffer(make([]byte, 0, newShip.bsize))
 	}
 
+	newShip.cvalues = append([]contextValue{}, s.cvalues...)
+	newShip.errfns = append([]func(*Context, error) error{}, s.errfns...)
 	newShip.Use(s.mws...)
 	newShip.Pre(s.pmws...)
 	newShip.SetBufferSize(2048)
 	return newShip
 }
 
+type contextValue struct {
+	key, value interface{}
+}
+
+// WithContextValue adds the key-value pair into the context.Context
+// of each request before dispatching it, so the value, such as a DB handle,
+// can be got by Request().Context().Value(key) in the handler and the code
+// consuming the standard context.Context, not only the ship Context.
+//
+// It should be called before handling any request.
+func (s *Ship) WithContextValue(key, value interface{}) *Ship {
+	if key == nil {
+		panic("WithContextValue: the key must not be nil")
+	}
+	s.cvalues = append(s.cvalues, contextValue{key: key, value: value})
+	return s
+}
+
+func withContextValues(req *http.Request, values []contextValue) *http.Request {
+	ctx := req.Context()
+	for _, kv := range values {
+		ctx = context.WithValue(ctx, kv.key, kv.value)
+	}
+	return req.WithContext(ctx)
+}
+
 // SetBufferSize resets the size of the buffer. The default is 2048.
 func (s *Ship) SetBufferSize(size int) {
 	if size < 0 {
@@ -214,14 +428,26 @@ func (s *Ship) SetBufferSize(size int) {
 func (s *Ship) NewContext() *Context {
 	c := NewContext(s.URLParamMaxNum, s.CtxDataInitCap)
 	c.BufferAllocator = s
+	c.ship = s
+	c.errorPages = s.pages
+	c.errorMaps = s.errmap
+	c.BaseURL = s.BaseURL
+	c.CleanPath = s.CleanPath
 	c.Logger = s.Logger
 	c.Router = s.Router
 	c.Session = s.Session
 	c.NotFound = s.NotFound
+	c.Fallback = s.Fallback
 	c.Binder = s.Binder
 	c.Renderer = s.Renderer
 	c.Responder = s.Responder
 	c.QueryBinder = s.BindQuery
+	c.QueryParser = s.QueryParser
+	c.MessageBundle = s.MessageBundle
+	c.CookieDefaults = s.CookieDefaults
+	c.OnRouteMatched = s.OnRouteMatched
+	c.IndexFiles = s.IndexFiles
+	c.MaxBodySize = s.MaxBodySize
 
 	if s.Defaulter == nil {
 		c.Defaulter = NothingDefaulter()
@@ -242,12 +468,18 @@ func (s *Ship) NewContext() *Context {
 func (s *Ship) AcquireContext(r *http.Request, w http.ResponseWriter) *Context {
 	c := s.cpool.Get().(*Context)
 	c.req, c.res.ResponseWriter = r, w
+	c.res.filter = s.ResponseFilter
 	return c
 }
 
-// ReleaseContext puts a Context into the pool.
+// ReleaseContext finishes the response and puts the Context into the pool.
 func (s *Ship) ReleaseContext(c *Context) {
+	c.res.Finish()
 	c.Reset()
+	if s.StrictContextReset {
+		c.resetStrict()
+		return
+	}
 	s.cpool.Put(c)
 }
 
@@ -299,7 +531,116 @@ func (s *Ship) Use(middlewares ...Middleware) {
 // Handle Request
 //----------------------------------------------------------------------------
 
+// SetErrorPage registers the render function of the error page
+// for the status code, which is used by the default error handler
+// to render the error page when the client prefers "text/html",
+// that's, the first accepted type is "text/html" or "application/xhtml+xml".
+// For other clients, such as the API clients, the default behavior is kept.
+//
+// If the error is not an HTTPServerError, the status code is 500.
+// And if render returns an error, fall back to the default behavior.
+//
+// Notice: it should be called before handling any request. If the error page
+// for the status code 404 is registered, the default NotFound handler returns
+// ErrNotFound for the client preferring "text/html" to render the error page
+// when not finding the route. But the custom NotFound handler must return
+// ErrNotFound by itself to do so.
+func (s *Ship) SetErrorPage(status int, render func(*Context, error) error) {
+	if render == nil {
+		panic("SetErrorPage: the render function must not be nil")
+	}
+	s.pages[status] = render
+}
+
+// MapError maps the sentinel error target, or the error wrapping it,
+// to the status code, which is used by the default error handler
+// to respond the error that is not HTTPServerError instead of 500.
+//
+// Notice: it should be called before handling any request.
+func (s *Ship) MapError(target error, status int) {
+	if target == nil {
+		panic("MapError: the target error must not be nil")
+	}
+	s.errmap.errs = append(s.errmap.errs, errorStatus{target: target, status: status})
+}
+
+// MapErrorType is the same as MapError, but maps the error whose type is
+// the type of prototype, or the error wrapping it, to HTTPServerError by fn.
+//
+// prototype is a value of the error type, such as MyError{} or
+// (*MyError)(nil). For the interface type, it should be the pointer to it,
+// such as (*interface{ Temporary() bool })(nil), which matches the error
+// implementing it.
+//
+// Notice: it should be called before handling any request.
+func (s *Ship) MapErrorType(prototype interface{}, fn func(error) HTTPServerError) {
+	if prototype == nil {
+		panic("MapErrorType: the error prototype must not be nil")
+	} else if fn == nil {
+		panic("MapErrorType: the mapping function must not be nil")
+	}
+
+	typ := reflect.TypeOf(prototype)
+	if typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Interface {
+		typ = typ.Elem()
+	} else if !typ.Implements(errorInterfaceType) {
+		panic("MapErrorType: the error prototype must implement error")
+	}
+	s.errmap.types = append(s.errmap.types, errorType{typ: typ, fn: fn})
+}
+
+// OnError appends the error transformer, which is called in turn
+// with the error returned by the handler or middleware before HandleError,
+// such as mapping the domain error to HTTPServerError.
+//
+// The error returned by the transformer is passed to the next one,
+// and HandleError is called with the final error. If a transformer
+// returns nil or ErrSkip, the rest transformers and HandleError are skipped.
+//
+// It should be called before handling any request.
+func (s *Ship) OnError(transformer func(c *Context, err error) error) {
+	if transformer == nil {
+		panic("OnError: the error transformer must not be nil")
+	}
+	s.errfns = append(s.errfns, transformer)
+}
+
+func (s *Ship) transformError(c *Context, err error) error {
+	for i, _len := 0, len(s.errfns); i < _len; i++ {
+		if err = s.errfns[i](c, err); err == nil || err == ErrSkip {
+			break
+		}
+	}
+	return err
+}
+
 func handleErrorDefault(ctx *Context, err error) {
+	// The error, such as context.DeadlineExceeded, may be caused by
+	// the server side, so only check whether the request is canceled.
+	if ctx.req.Context().Err() != nil {
+		ctx.Logger.Debugf("the client has disconnected: %s", err)
+		return
+	}
+
+	if _, ok := err.(HTTPServerError); !ok {
+		if se, ok := ctx.errorMaps.Map(err); ok {
+			err = se
+		}
+	}
+
+	if !ctx.res.Wrote && len(ctx.errorPages) > 0 && ctx.acceptHTML() {
+		code := http.StatusInternalServerError
+		if se, ok := err.(HTTPServerError); ok {
+			code = se.Code
+		}
+
+		if render, ok := ctx.errorPages[code]; ok {
+			if rerr := render(ctx, err); rerr != nil {
+				ctx.Logger.Errorf("fail to render the error page for %d: %s", code, rerr)
+			}
+		}
+	}
+
 	if !ctx.res.Wrote {
 		if se, ok := err.(HTTPServerError); !ok {
 			ctx.NoContent(http.StatusInternalServerError)
@@ -314,15 +655,143 @@ func handleErrorDefault(ctx *Context, err error) {
 // HandleRequest is the same as ServeHTTP, but handles the request
 // with the Context.
 func (s *Ship) HandleRequest(c *Context) error { return s.handler(c) }
-func (s *Ship) handleRequest(c *Context) error { return c.Execute() }
+func (s *Ship) handleRequest(c *Context) error {
+	switch c.req.Method {
+	case http.MethodHead:
+		if s.AutoHEAD {
+			return s.handleHEAD(c)
+		}
+	case http.MethodOptions:
+		if s.AutoOptions {
+			return s.handleOPTIONS(c)
+		}
+	}
+	return c.Execute()
+}
+
+func (s *Ship) handleOPTIONS(c *Context) (err error) {
+	var methods []string
+	if path := c.matchPath(); c.req.URL.Path == "*" || c.req.RequestURI == "*" {
+		methods = s.allMethods()
+	} else {
+		h, n := c.Router.Match(path, http.MethodOptions, c.pnames, c.pvalues)
+		if _, ok := h.(Route); ok {
+			return c.executeHandler(h, n)
+		}
+
+		if methods = s.AllowedMethods(path); len(methods) == 0 {
+			return c.Execute()
+		}
+	}
+
+	if !InStrings(http.MethodOptions, methods) {
+		methods = append(methods, http.MethodOptions)
+	}
+
+	c.SetRespHeader(HeaderAllow, strings.Join(methods, ", "))
+	return c.NoContent(http.StatusNoContent)
+}
+
+// allMethods returns all the methods registered for any path.
+func (s *Ship) allMethods() []string {
+	methods := make([]string, 0, len(standardMethods))
+	for _, r := range s.Routes() {
+		if r.Method == "" {
+			return s.appendMethods(append([]string{}, standardMethods...))
+		} else if !InStrings(r.Method, methods) {
+			methods = append(methods, r.Method)
+		}
+	}
+	return s.appendMethods(methods)
+}
+
+// AllowedMethods returns the methods registered for the path,
+// which may be used to build the response header "Allow".
+//
+// If the router has implemented the interface router.MethodsRouter,
+// use it. Or, match the path with the standard methods one by one.
+func (s *Ship) AllowedMethods(path string) []string {
+	if mr, ok := s.Router.(router.MethodsRouter); ok {
+		return s.appendMethods(mr.Methods(path))
+	}
+
+	methods := make([]string, 0, len(standardMethods))
+	for _, method := range standardMethods {
+		if h, _ := s.Router.Match(path, method, nil, nil); h != nil {
+			if _, ok := h.(Route); ok {
+				methods = append(methods, method)
+			}
+		}
+	}
+	return s.appendMethods(methods)
+}
+
+func (s *Ship) appendMethods(methods []string) []string {
+	if s.AutoHEAD && InStrings(http.MethodGet, methods) &&
+		!InStrings(http.MethodHead, methods) {
+		methods = append(methods, http.MethodHead)
+	}
+	return methods
+}
+
+var standardMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+func (s *Ship) handleHEAD(c *Context) (err error) {
+	path := c.matchPath()
+	h, n := c.Router.Match(path, http.MethodHead, c.pnames, c.pvalues)
+	if _, ok := h.(Route); ok {
+		return c.executeHandler(h, n)
+	}
+
+	h, n = c.Router.Match(path, http.MethodGet, c.pnames, c.pvalues)
+	if _, ok := h.(Route); !ok {
+		return c.Execute()
+	}
+
+	w := c.res.ResponseWriter
+	c.res.SetWriter(&headResponse{ResponseWriter: w})
+	err = c.executeHandler(h, n)
+	c.res.flushFiltered()
+	c.res.SetWriter(w)
+	return
+}
 
 // ServeHTTP implements the interface http.Handler.
 func (s *Ship) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if len(s.cvalues) > 0 {
+		req = withContextValues(req, s.cvalues)
+	}
+
 	c := s.AcquireContext(req, resp)
-	switch err := s.handler(c); err {
+	s.serveContext(c)
+	s.ReleaseContext(c)
+}
+
+// serveContext dispatches the context c through the handler and handles
+// the returned error, which is shared by ServeHTTP and Context.SubRequest.
+func (s *Ship) serveContext(c *Context) {
+	var err error
+	if s.MaxPathLength > 0 && len(c.req.URL.Path) > s.MaxPathLength {
+		err = ErrStatusRequestURITooLong
+	} else {
+		err = s.handler(c)
+	}
+
+	switch err {
 	case nil, ErrSkip:
 	default:
-		s.HandleError(c, err)
+		if err = s.transformError(c, err); err != nil && err != ErrSkip {
+			s.HandleError(c, err)
+		}
 	}
-	s.ReleaseContext(c)
 }
@@ -15,9 +15,16 @@
 package ship
 
 import (
+	"bytes"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+
+	"github.com/xgfone/ship/v5/router"
 )
 
 var noHandler = NothingHandler()
@@ -40,6 +47,25 @@ func BenchmarkShipWithoutVHost(b *testing.B) {
 	}
 }
 
+func BenchmarkShipWithLockRouter(b *testing.B) {
+	s := New()
+	s.Router = router.NewLockRouter(s.Router)
+	s.Route("/path1").GET(noHandler)
+	s.Route("/path2").GET(noHandler)
+
+	req, err := http.NewRequest(http.MethodGet, "http://www.example.com/path2", nil)
+	rec := httptest.NewRecorder()
+	if err != nil {
+		panic(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.ServeHTTP(rec, req)
+	}
+}
+
 func BenchmarkShipWithExactVHost(b *testing.B) {
 	vhost := New()
 	vhost.Route("/path1").GET(noHandler)
@@ -103,3 +129,103 @@ func BenchmarkShipWithRegexpVHost(b *testing.B) {
 		vhosts.ServeHTTP(rec, req)
 	}
 }
+
+type newBufferAllocator struct{}
+
+func (newBufferAllocator) AcquireBuffer() *bytes.Buffer {
+	return bytes.NewBuffer(make([]byte, 0, 2048))
+}
+func (newBufferAllocator) ReleaseBuffer(*bytes.Buffer) {}
+
+func benchmarkContextEncode(b *testing.B, xml bool, size int) {
+	data := make([]string, 1024)
+	for i := range data {
+		data[i] = strings.Repeat("a", 60)
+	}
+
+	c := NewContext(0, 0)
+	c.BufferAllocator = newBufferAllocator{}
+	c.SetReqResp(httptest.NewRequest(http.MethodGet, "/", nil), discardResponseWriter{})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.res.Reset(discardResponseWriter{})
+		if xml {
+			c.XMLWithBufSize(200, data, size)
+		} else {
+			c.JSONWithBufSize(200, data, size)
+		}
+	}
+}
+
+func BenchmarkContextJSON(b *testing.B)            { benchmarkContextEncode(b, false, 0) }
+func BenchmarkContextJSONWithBufSize(b *testing.B) { benchmarkContextEncode(b, false, 70000) }
+func BenchmarkContextXML(b *testing.B)             { benchmarkContextEncode(b, true, 0) }
+func BenchmarkContextXMLWithBufSize(b *testing.B)  { benchmarkContextEncode(b, true, 100000) }
+
+type discardResponseWriter struct{}
+
+func (discardResponseWriter) Header() http.Header         { return http.Header{} }
+func (discardResponseWriter) WriteHeader(int)             {}
+func (discardResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+func benchmarkServeLargeFile(b *testing.B, stream bool) {
+	f, err := ioutil.TempFile("", "ship")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(bytes.Repeat([]byte("a"), 8*1024*1024))
+	f.Close()
+
+	s := New()
+	s.Route("/file").GET(func(c *Context) error {
+		if !stream {
+			return c.File(f.Name())
+		}
+
+		file, err := os.Open(f.Name())
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return c.Stream(200, MIMEOctetStream, file)
+	})
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := http.Get(server.URL + "/file")
+		if err != nil {
+			b.Fatal(err)
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+func BenchmarkContextFile(b *testing.B)   { benchmarkServeLargeFile(b, false) }
+func BenchmarkContextStream(b *testing.B) { benchmarkServeLargeFile(b, true) }
+
+func BenchmarkContextQueries(b *testing.B) {
+	router := New()
+	router.Route("/search").GET(func(c *Context) error {
+		_ = c.Query("q")
+		_ = c.Query("page")
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/search?q=golang+web&page=2&page_size=20&sort=-created_at&tags=a&tags=b", nil)
+	rec := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(rec, req)
+	}
+}
@@ -17,12 +17,17 @@ package ship
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"sort"
 	"strings"
+
+	"github.com/xgfone/ship/v5/router"
 )
 
 var (
 	errInvalidHandler   = errors.New("handler must not be nil")
 	errTooManyURLParams = errors.New("too many url params")
+	errNotSupportPrio   = errors.New("router does not support the route priority")
 )
 
 // Route is used to represent the information of the registered route.
@@ -42,6 +47,34 @@ type Route struct {
 
 	// Data is any additional data associated with the route.
 	Data interface{} `json:"data,omitempty" xml:"data,omitempty"`
+
+	// Meta is the metadata of the route set by RouteBuilder.Meta,
+	// such as the API documentation, which is not used to handle
+	// the request, unlike Data.
+	Meta map[string]interface{} `json:"meta,omitempty" xml:"-"`
+
+	// Middlewares is the names of the middlewares of the route
+	// in the order of execution, which is only used to debug.
+	Middlewares []string `json:"middlewares,omitempty" xml:"middlewares,omitempty"`
+
+	// Priority is the priority of the route path as the tiebreaker
+	// to match the route, which requires that the router has implemented
+	// the interface router.PriorityRouter.
+	//
+	// Default: 0
+	Priority int `json:"priority,omitempty" xml:"priority,omitempty"`
+
+	// Host is the host of the request that the route only matches,
+	// such as "www.example.com", or "*.example.com" to match the subdomains.
+	// The port of the request host is ignored. So more than one route
+	// with the different hosts can be registered for the same path and method,
+	// like the matcher set by RouteBuilder.Match.
+	//
+	// Default: "", which matches any host
+	Host string `json:"host,omitempty" xml:"host,omitempty"`
+
+	matcher func(*Context) bool
+	guards  *routeGuards
 }
 
 func (r Route) String() string {
@@ -66,15 +99,64 @@ func (r Route) checkPath() error {
 	return nil
 }
 
-// Routes returns the information of all the routes.
+// Routes returns the information of all the routes, which also contains
+// all the candidate routes registered for the same path and method
+// by RouteBuilder.Match or RouteBuilder.Host.
 func (s *Ship) Routes() (routes []Route) {
 	routes = make([]Route, 0, 16)
 	s.Router.Range(func(name, path, method string, handler interface{}) {
-		routes = append(routes, handler.(Route))
+		walkRoute(handler.(Route), func(r Route) bool {
+			routes = append(routes, r)
+			return true
+		})
 	})
 	return
 }
 
+// WalkRoutes traverses the registered routes without allocating
+// the slice of all the routes, and stops when fn returns false,
+// which is useful to find a specific route or build an index.
+//
+// Like Routes, the candidate routes for the same path and method
+// are traversed in turn.
+//
+// If the router has not implemented the interface router.WalkRouter,
+// the rest routes are still traversed but ignored after fn returns false.
+func (s *Ship) WalkRoutes(fn func(Route) bool) {
+	if wr, ok := s.Router.(router.WalkRouter); ok {
+		wr.Walk(func(name, path, method string, handler interface{}) bool {
+			return walkRoute(handler.(Route), fn)
+		})
+		return
+	}
+
+	ok := true
+	s.Router.Range(func(name, path, method string, handler interface{}) {
+		if ok {
+			ok = walkRoute(handler.(Route), fn)
+		}
+	})
+}
+
+// walkRoute calls fn with the route registered into the router,
+// or with its candidate routes in turn if it is guarded.
+func walkRoute(route Route, fn func(Route) bool) bool {
+	if route.guards == nil {
+		return fn(route)
+	}
+
+	for _, r := range route.guards.guarded {
+		if !fn(r) {
+			return false
+		}
+	}
+
+	if route.guards.fallback != nil {
+		return fn(*route.guards.fallback)
+	}
+	return true
+}
+
 // AddRoutes registers a set of the routes.
 //
 // It will panic with it if there is an error when adding the routes.
@@ -97,20 +179,166 @@ func (s *Ship) DelRoutes(routes ...Route) {
 	}
 }
 
+// Mount is short for s.Route(prefix).Mount(handler).
+func (s *Ship) Mount(prefix string, handler http.Handler) {
+	s.Route(prefix).Mount(handler)
+}
+
+// RoutesHandler returns a handler to respond all the routes as JSON,
+// which are the list of HostRoute, including the routes of s and those
+// of the virtual hosts managed by hosts, such as *HostManagerHandler.
+//
+// For the routes of s, the host is Route.Host, which may be empty.
+func (s *Ship) RoutesHandler(hosts ...HostManager) Handler {
+	return func(c *Context) error {
+		routes := s.Routes()
+		hrs := make([]HostRoute, 0, len(routes))
+		for _, r := range routes {
+			hrs = append(hrs, HostRoute{Host: r.Host, Route: r})
+		}
+
+		for _, hm := range hosts {
+			hrs = append(hrs, HostRoutes(hm)...)
+		}
+
+		return c.JSON(http.StatusOK, hrs)
+	}
+}
+
+// TryAddRoutes is the same as AddRoutes, but returns the error
+// instead of panicking.
+//
+// All the routes are validated before registering any of them, and
+// RouteErrors containing all the invalid routes is returned if some fail.
+// Or, register them in turn and return the first error, and the routes
+// of the same paths are rolled back to the state before registering,
+// so that either all or none of the routes are registered.
+func (s *Ship) TryAddRoutes(routes ...Route) (err error) {
+	var errs RouteErrors
+	valids := make([]Route, 0, len(routes))
+	for _, r := range routes {
+		if ok, err := s.checkRoute(&r); err != nil {
+			errs = append(errs, err.(RouteError))
+		} else if ok {
+			valids = append(valids, r)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	snapshot := s.snapshotRoutes(valids)
+	for _, r := range valids {
+		if err = s.addRoute(r); err != nil {
+			s.restoreRoutes(snapshot)
+			return
+		}
+	}
+
+	return
+}
+
+// routeSnapshot is the registered routes of some paths to be restored.
+type routeSnapshot struct {
+	paths  map[string][]routeEntry // Path -> Routes
+	guards map[string]*routeGuards // Guard Key -> Guards
+}
+
+type routeEntry struct {
+	name   string
+	method string
+	route  Route
+}
+
+func (s *Ship) snapshotRoutes(routes []Route) (snapshot routeSnapshot) {
+	snapshot.paths = make(map[string][]routeEntry, len(routes))
+	snapshot.guards = make(map[string]*routeGuards, len(routes))
+	for _, r := range routes {
+		key := routeGuardKey(r.Path, r.Method)
+		snapshot.guards[key] = s.guards[key]
+		snapshot.paths[r.Path] = nil
+	}
+
+	s.Router.Range(func(name, path, method string, handler interface{}) {
+		if entries, ok := snapshot.paths[path]; ok {
+			route, _ := handler.(Route)
+			entry := routeEntry{name: name, method: method, route: route}
+			snapshot.paths[path] = append(entries, entry)
+		}
+	})
+	return
+}
+
+func (s *Ship) restoreRoutes(snapshot routeSnapshot) {
+	pr, _ := s.Router.(router.PriorityRouter)
+	for path, entries := range snapshot.paths {
+		s.Router.Del(path, "")
+
+		// The route of any method must be restored before others,
+		// because it overrides the routes of all the methods.
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].method == "" && entries[j].method != ""
+		})
+
+		for _, e := range entries {
+			s.Router.Add(e.name, path, e.method, e.route)
+			if pr != nil && e.route.Priority != 0 {
+				pr.SetPriority(path, e.method, e.route.Priority)
+			}
+		}
+	}
+
+	for key, guards := range snapshot.guards {
+		if guards == nil {
+			delete(s.guards, key)
+		} else {
+			s.guards[key] = guards
+		}
+	}
+}
+
 // AddRoute registers the route.
 func (s *Ship) AddRoute(r Route) (err error) {
-	ok, err := s.checkRouteInfo(&r)
+	ok, err := s.checkRoute(&r)
 	if err != nil || !ok {
 		return
-	} else if r.Handler == nil {
-		return RouteError{Route: r, Err: errInvalidHandler}
+	}
+	return s.addRoute(r)
+}
+
+func (s *Ship) addRoute(r Route) (err error) {
+	pr, ok := s.Router.(router.PriorityRouter)
+	if r.Priority != 0 && !ok {
+		return RouteError{Route: r, Err: errNotSupportPrio}
+	}
+
+	if r.Host != "" {
+		r.matcher = newHostMatcher(r.Host, r.matcher)
 	}
 
-	if n, _err := s.Router.Add(r.Name, r.Path, r.Method, r); _err != nil {
+	route := s.guardRoute(r)
+	if n, _err := s.Router.Add(r.Name, r.Path, r.Method, route); _err != nil {
 		err = RouteError{Route: r, Err: _err}
 	} else if n > s.URLParamMaxNum {
 		s.Router.Del(r.Path, r.Method)
 		err = RouteError{Route: r, Err: errTooManyURLParams}
+	} else {
+		if route.guards != nil {
+			if s.guards == nil {
+				s.guards = make(map[string]*routeGuards, 4)
+			}
+			s.guards[routeGuardKey(r.Path, r.Method)] = route.guards
+		}
+
+		if r.Priority != 0 {
+			pr.SetPriority(r.Path, r.Method, r.Priority)
+		}
+
+		if s.Debug && s.Logger != nil {
+			s.Logger.Debugf("register route: method=%s, path=%s, middlewares=[%s]",
+				r.Method, r.Path, strings.Join(r.Middlewares, ", "))
+		}
 	}
 
 	return
@@ -119,10 +347,20 @@ func (s *Ship) AddRoute(r Route) (err error) {
 // DelRoute deletes the registered route, which only uses "Path" and "Method",
 // and others are ignored.
 //
+// If Path is empty but Name is not, the path is looked up by the route name.
 // If Method is empty, deletes all the routes associated with the path.
 //
+// Notice: all the candidate routes registered for the same path and method
+// by RouteBuilder.Match or RouteBuilder.Host are deleted together.
+//
 // If the route does not exist, do nothing and return nil.
 func (s *Ship) DelRoute(r Route) (err error) {
+	if r.Path == "" && r.Name != "" {
+		if r.Path = s.Router.Path(r.Name); r.Path == "" {
+			return
+		}
+	}
+
 	ok, err := s.checkRouteInfo(&r)
 	if !ok || err != nil {
 		return
@@ -130,11 +368,20 @@ func (s *Ship) DelRoute(r Route) (err error) {
 
 	if err = s.Router.Del(r.Path, r.Method); err != nil {
 		err = RouteError{Route: r, Err: err}
+	} else {
+		s.unguardRoute(r)
 	}
 
 	return
 }
 
+func (s *Ship) checkRoute(r *Route) (ok bool, err error) {
+	if ok, err = s.checkRouteInfo(r); err == nil && ok && r.Handler == nil {
+		ok, err = false, RouteError{Route: *r, Err: errInvalidHandler}
+	}
+	return
+}
+
 func (s *Ship) checkRouteInfo(r *Route) (ok bool, err error) {
 	r.Method = strings.ToUpper(r.Method)
 	if s.RouteModifier != nil {
@@ -153,3 +400,97 @@ func (s *Ship) checkRouteInfo(r *Route) (ok bool, err error) {
 
 	return
 }
+
+// routeGuards is the candidate routes registered for the same path and method,
+// some of which have the matchers set by RouteBuilder.Match.
+type routeGuards struct {
+	guarded  []Route
+	fallback *Route
+}
+
+func (g *routeGuards) Handle(c *Context) error {
+	for i, _len := 0, len(g.guarded); i < _len; i++ {
+		if g.guarded[i].matcher(c) {
+			c.Route = g.guarded[i]
+			return c.Route.Handler(c)
+		}
+	}
+
+	if g.fallback != nil {
+		c.Route = *g.fallback
+		return c.Route.Handler(c)
+	}
+
+	return c.NotFound(c)
+}
+
+// newHostMatcher returns a route matcher to match the request host by host,
+// which also calls the original matcher if it is not nil.
+func newHostMatcher(host string, matcher func(*Context) bool) func(*Context) bool {
+	host = strings.ToLower(host)
+	return func(c *Context) bool {
+		return matchRouteHost(host, c.req.Host) && (matcher == nil || matcher(c))
+	}
+}
+
+func matchRouteHost(pattern, host string) bool {
+	if host == "" {
+		return false
+	}
+
+	host = strings.ToLower(splitHost(host))
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return host == pattern
+}
+
+func routeGuardKey(path, method string) string { return method + " " + path }
+
+// guardRoute returns the route to be registered into the router,
+// which dispatches the request to the candidate routes if there are
+// the routes with the matcher for the same path and method.
+//
+// The candidate routes are copied into the new guards of the returned route,
+// which should be saved only after the route is registered successfully.
+func (s *Ship) guardRoute(r Route) Route {
+	old, ok := s.guards[routeGuardKey(r.Path, r.Method)]
+	if !ok {
+		if r.matcher == nil {
+			return r
+		}
+
+		old = &routeGuards{}
+		s.Router.Range(func(_, path, method string, h interface{}) {
+			if path == r.Path && method == r.Method {
+				if route, ok := h.(Route); ok && route.guards == nil {
+					old.fallback = &route
+				}
+			}
+		})
+	}
+
+	guards := &routeGuards{fallback: old.fallback}
+	guards.guarded = make([]Route, len(old.guarded), len(old.guarded)+1)
+	copy(guards.guarded, old.guarded)
+	if r.matcher == nil {
+		guards.fallback = &r
+	} else {
+		guards.guarded = append(guards.guarded, r)
+	}
+
+	route := r
+	route.Handler = guards.Handle
+	route.matcher = nil
+	route.guards = guards
+	return route
+}
+
+func (s *Ship) unguardRoute(r Route) {
+	for key := range s.guards {
+		if key == routeGuardKey(r.Path, r.Method) ||
+			(r.Method == "" && strings.HasSuffix(key, " "+r.Path)) {
+			delete(s.guards, key)
+		}
+	}
+}
@@ -17,10 +17,13 @@ package ship
 import (
 	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
 	"os"
 	"path"
+	"sort"
 	"strings"
+	"time"
 )
 
 type kvalues struct {
@@ -32,10 +35,16 @@ type kvalues struct {
 type RouteBuilder struct {
 	ship    *Ship
 	group   *RouteGroupBuilder
+	prefix  string
 	path    string
+	paths   []string
 	name    string
 	data    interface{}
+	meta    map[string]interface{}
 	mdwares []Middleware
+	matcher func(*Context) bool
+	prio    int
+	host    string
 }
 
 func newRouteBuilder(s *Ship, g *RouteGroupBuilder, prefix, path string,
@@ -49,6 +58,7 @@ func newRouteBuilder(s *Ship, g *RouteGroupBuilder, prefix, path string,
 	return &RouteBuilder{
 		ship:    s,
 		group:   g,
+		prefix:  strings.TrimSuffix(prefix, "/"),
 		path:    strings.TrimSuffix(prefix, "/") + path,
 		mdwares: append([]Middleware{}, ms...),
 		data:    data,
@@ -67,11 +77,17 @@ func (r *RouteBuilder) Ship() *Ship { return r.ship }
 func (r *RouteBuilder) Clone() *RouteBuilder {
 	return &RouteBuilder{
 		data:    r.data,
+		meta:    r.meta,
 		ship:    r.ship,
+		prefix:  r.prefix,
 		path:    r.path,
+		paths:   append([]string{}, r.paths...),
 		name:    r.name,
 		group:   r.group,
 		mdwares: append([]Middleware{}, r.mdwares...),
+		matcher: r.matcher,
+		prio:    r.prio,
+		host:    r.host,
 	}
 }
 
@@ -85,6 +101,112 @@ func (r *RouteBuilder) Use(middlewares ...Middleware) *RouteBuilder {
 	return r
 }
 
+// ValidateBody compiles the schema by Ship.SchemaCompiler and appends
+// the middleware ValidateBody to validate the request body.
+//
+// It will panic if Ship.SchemaCompiler is nil or fails to compile the schema.
+func (r *RouteBuilder) ValidateBody(schema []byte) *RouteBuilder {
+	if r.ship.SchemaCompiler == nil {
+		panic("the schema compiler of ship is nil")
+	}
+
+	v, err := r.ship.SchemaCompiler.Compile(schema)
+	if err != nil {
+		panic(fmt.Errorf("fail to compile the schema: %s", err))
+	}
+
+	return r.Use(ValidateBody(v))
+}
+
+// ContextValue appends a middleware to add the key-value pair into
+// the context.Context of the request of the route,
+// which is the per-route variant of Ship.WithContextValue.
+func (r *RouteBuilder) ContextValue(key, value interface{}) *RouteBuilder {
+	if key == nil {
+		panic("RouteBuilder.ContextValue: the key must not be nil")
+	}
+
+	values := []contextValue{{key: key, value: value}}
+	return r.Use(func(next Handler) Handler {
+		return func(c *Context) error {
+			c.req = withContextValues(c.req, values)
+			return next(c)
+		}
+	})
+}
+
+// Accepts appends a middleware to reject the request with the status code 415
+// if its Content-Type is not one of contentTypes.
+//
+// Notice: the request without the Content-Type and the body is not checked.
+func (r *RouteBuilder) Accepts(contentTypes ...string) *RouteBuilder {
+	if len(contentTypes) == 0 {
+		panic("RouteBuilder.Accepts: the content types must not be empty")
+	}
+
+	return r.Use(func(next Handler) Handler {
+		return func(c *Context) error {
+			ct := c.ContentType()
+			if ct == "" && c.req.ContentLength == 0 {
+				return next(c)
+			}
+
+			for _, _ct := range contentTypes {
+				if strings.EqualFold(ct, _ct) {
+					return next(c)
+				}
+			}
+			return ErrUnsupportedMediaType
+		}
+	})
+}
+
+// Produces appends a middleware to reject the request with the status code 406
+// if the client cannot accept any of contentTypes by Context.Negotiate,
+// which may be called by the handler to choose the content type of the response.
+func (r *RouteBuilder) Produces(contentTypes ...string) *RouteBuilder {
+	if len(contentTypes) == 0 {
+		panic("RouteBuilder.Produces: the content types must not be empty")
+	}
+
+	return r.Use(func(next Handler) Handler {
+		return func(c *Context) error {
+			if c.Negotiate(contentTypes...) == "" {
+				return ErrStatusNotAcceptable
+			}
+			return next(c)
+		}
+	})
+}
+
+// Deprecated appends a middleware to add the response headers
+// "Deprecation: true", "Sunset: <HTTP-date>" if sunset is not zero,
+// and "Link: <link>; rel=\"sunset\"" if link is not empty,
+// to signal the clients that the route has been deprecated.
+func (r *RouteBuilder) Deprecated(sunset time.Time, link string) *RouteBuilder {
+	var sunsetDate, sunsetLink string
+	if !sunset.IsZero() {
+		sunsetDate = sunset.UTC().Format(http.TimeFormat)
+	}
+	if link != "" {
+		sunsetLink = fmt.Sprintf(`<%s>; rel="sunset"`, link)
+	}
+
+	return r.Use(func(next Handler) Handler {
+		return func(c *Context) error {
+			header := c.res.Header()
+			header.Set("Deprecation", "true")
+			if sunsetDate != "" {
+				header.Set("Sunset", sunsetDate)
+			}
+			if sunsetLink != "" {
+				header.Add(HeaderLink, sunsetLink)
+			}
+			return next(c)
+		}
+	})
+}
+
 // ResetMiddlewares resets the middlewares to ms.
 func (r *RouteBuilder) ResetMiddlewares(ms ...Middleware) *RouteBuilder {
 	r.mdwares = append([]Middleware{}, ms...)
@@ -97,48 +219,203 @@ func (r *RouteBuilder) Name(name string) *RouteBuilder {
 	return r
 }
 
-// Data sets the context data.
+// Paths appends the alias paths, which are registered together with
+// the route path by Routes, Method, MethodE and the shortcuts such as GET,
+// and share the same handler wrapped by the middlewares, which is built
+// only once. Like the route path, the alias paths are prefixed with
+// the prefix of the group or ship.
+//
+// Notice: the route name is only associated with the route path, not
+// the alias paths, because the route name must be unique.
+func (r *RouteBuilder) Paths(paths ...string) *RouteBuilder {
+	for _, p := range paths {
+		if p == "" {
+			panic("the route path must not be empty")
+		} else if p[0] != '/' {
+			panic(fmt.Errorf("path '%s' must start with '/'", p))
+		}
+		r.paths = append(r.paths, r.prefix+p)
+	}
+	return r
+}
+
+// Data sets the context data, which is able to be read by the middlewares
+// and the handler of the route by Context.RouteData.
 func (r *RouteBuilder) Data(data interface{}) *RouteBuilder {
 	r.data = data
 	return r
 }
 
-func (r *RouteBuilder) newRoutes(name, path string, handler Handler,
+// Meta sets the metadata of the route by the key, which is stored into
+// Route.Meta, such as the API documentation used by the package openapi.
+//
+// The metadata map is copied before setting it, so the routes that have
+// been built by the builder are not affected.
+func (r *RouteBuilder) Meta(key string, value interface{}) *RouteBuilder {
+	r.meta = copyRouteDataMap(r.meta)
+	r.meta[key] = value
+	return r
+}
+
+// Match sets the matcher of the route, so that more than one route
+// with the different matchers can be registered for the same path and method.
+//
+// When the request arrives, the routes with the matchers are tried
+// in the order of registration, and the first one whose matcher returns true
+// handles the request. If none of them matches, the route registered
+// without the matcher for the same path and method, whenever it is registered,
+// handles the request as the fallback. Or, it is handled as not found.
+//
+// For example,
+//
+//    router.Route("/path").Match(isV2).GET(handlerV2)
+//    router.Route("/path").GET(handlerV1) // the fallback
+func (r *RouteBuilder) Match(matcher func(*Context) bool) *RouteBuilder {
+	if matcher == nil {
+		panic("RouteBuilder: the matcher must not be nil")
+	}
+	r.matcher = matcher
+	return r
+}
+
+// Host sets the host of the request that the route only matches,
+// such as "www.example.com" or "*.example.com", so that a single route
+// is able to be restricted to the host without creating the virtual host.
+//
+// Like Match, the routes with the different hosts can be registered
+// for the same path and method, and the route without the host
+// handles the request of other hosts as the fallback. For example,
+//
+//    router.Route("/path").Host("api.example.com").GET(apiHandler)
+//    router.Route("/path").Host("*.example.com").GET(subdomainHandler)
+//    router.Route("/path").GET(defaultHandler) // the fallback
+func (r *RouteBuilder) Host(host string) *RouteBuilder {
+	r.host = host
+	return r
+}
+
+// Priority sets the priority of the route path as the tiebreaker to match
+// the route, which requires that the router has implemented the interface
+// router.PriorityRouter, such as the default echo router.
+//
+// The route with the higher priority wins when more than one route match
+// the request path, for example,
+//
+//    router.Route("/posts/new").GET(newHandler)
+//    router.Route("/posts/:slug").Priority(1).GET(slugHandler)
+//
+// The request "/posts/new" is handled by slugHandler instead of newHandler.
+func (r *RouteBuilder) Priority(priority int) *RouteBuilder {
+	r.prio = priority
+	return r
+}
+
+// RouteDataScopesKey is the key of the route data to store the required
+// scopes of the route, which is set by RouteBuilder.Scopes.
+const RouteDataScopesKey = "scopes"
+
+// Scopes sets the required scopes of the route, which is stored into
+// the route data of the type map[string]interface{} by the key
+// RouteDataScopesKey and is able to be read by Context.RouteScopes.
+//
+// If the route data has been set and is not map[string]interface{}, panic.
+//
+// The route data map is copied before setting the scopes, so the routes
+// that have been built by the builder are not affected.
+func (r *RouteBuilder) Scopes(scopes ...string) *RouteBuilder {
+	switch data := r.data.(type) {
+	case nil:
+		r.data = map[string]interface{}{RouteDataScopesKey: scopes}
+	case map[string]interface{}:
+		data = copyRouteDataMap(data)
+		data[RouteDataScopesKey] = scopes
+		r.data = data
+	default:
+		panic(fmt.Errorf("the route data is not map[string]interface{}, but %T", r.data))
+	}
+	return r
+}
+
+func copyRouteDataMap(data map[string]interface{}) map[string]interface{} {
+	newdata := make(map[string]interface{}, len(data)+1)
+	for key, value := range data {
+		newdata[key] = value
+	}
+	return newdata
+}
+
+func (r *RouteBuilder) newRoutes(name string, paths []string, handler Handler,
 	methods ...string) []Route {
+	routes, err := r.buildRoutes(name, paths, handler, methods...)
+	if err != nil {
+		panic(err)
+	}
+	return routes
+}
+
+func (r *RouteBuilder) buildRoutes(name string, paths []string, handler Handler,
+	methods ...string) ([]Route, error) {
 	if len(methods) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	middlewaresLen := len(r.mdwares)
 	if middlewaresLen > r.ship.MiddlewareMaxNum {
-		panic(fmt.Errorf("the number of middlewares '%d' has exceeded the maximum '%d'",
-			middlewaresLen, r.ship.MiddlewareMaxNum))
+		return nil, fmt.Errorf("the number of middlewares '%d' has exceeded the maximum '%d'",
+			middlewaresLen, r.ship.MiddlewareMaxNum)
 	}
 
+	mwnames := make([]string, middlewaresLen)
 	for i := middlewaresLen - 1; i >= 0; i-- {
 		handler = r.mdwares[i](handler)
+		mwnames[i] = middlewareName(r.mdwares[i])
+	}
+
+	// Copy the route data map so that the built routes do not share it
+	// with the builder, which may be modified later, such as by Scopes.
+	data := r.data
+	if m, ok := data.(map[string]interface{}); ok {
+		data = copyRouteDataMap(m)
 	}
 
-	routes := make([]Route, len(methods))
-	for i, method := range methods {
-		routes[i] = Route{
-			Name:    name,
-			Path:    path,
-			Method:  method,
-			Handler: handler,
-			Data:    r.data,
+	routes := make([]Route, 0, len(paths)*len(methods))
+	for i, path := range paths {
+		if i > 0 {
+			name = "" // The alias paths have no name.
+		}
+
+		for _, method := range methods {
+			routes = append(routes, Route{
+				Name:    name,
+				Path:    path,
+				Method:  method,
+				Handler: handler,
+				Data:    data,
+				Meta:    r.meta,
+
+				Middlewares: mwnames,
+				Priority:    r.prio,
+				Host:        r.host,
+
+				matcher: r.matcher,
+			})
 		}
 	}
-	return routes
+	return routes, nil
 }
 
 func (r *RouteBuilder) addRoute(name, path string, h Handler, ms ...string) {
-	r.ship.AddRoutes(r.newRoutes(name, path, h, ms...)...)
+	r.ship.AddRoutes(r.newRoutes(name, []string{path}, h, ms...)...)
 }
 
-// Routes builds and returns the routes.
+func (r *RouteBuilder) allPaths() []string {
+	return append([]string{r.path}, r.paths...)
+}
+
+// Routes builds and returns the routes, including the routes of the alias
+// paths appended by Paths.
 func (r *RouteBuilder) Routes(handler Handler, methods ...string) []Route {
-	return r.newRoutes(r.name, r.path, handler, methods...)
+	return r.newRoutes(r.name, r.allPaths(), handler, methods...)
 }
 
 // Method registers the routes with the handler and methods.
@@ -149,6 +426,16 @@ func (r *RouteBuilder) Method(handler Handler, methods ...string) *RouteBuilder
 	return r
 }
 
+// MethodE is the same as Method, but returns the error instead of panicking,
+// such as the conflicting route name or too many url parameters.
+func (r *RouteBuilder) MethodE(handler Handler, methods ...string) error {
+	routes, err := r.buildRoutes(r.name, r.allPaths(), handler, methods...)
+	if err != nil {
+		return err
+	}
+	return r.ship.TryAddRoutes(routes...)
+}
+
 // Any registers all the supported methods , which is short for
 // r.Method(handler, "")
 func (r *RouteBuilder) Any(handler Handler) *RouteBuilder {
@@ -231,11 +518,19 @@ func (r *RouteBuilder) Map(method2handlers map[string]Handler) *RouteBuilder {
 }
 
 // StaticFS registers a route to serve a static filesystem.
+//
+// For the directory, it serves the first existing index file
+// in Ship.IndexFiles, which is used when registering the route.
 func (r *RouteBuilder) StaticFS(fs http.FileSystem) *RouteBuilder {
 	if strings.Contains(r.path, ":") || strings.Contains(r.path, "*") {
 		panic(errors.New("URL parameters cannot be used when serving a static file"))
 	}
 
+	files := r.ship.IndexFiles
+	if len(files) > 0 && (len(files) != 1 || files[0] != "index.html") {
+		fs = indexFileFS{fs: fs, indexes: append([]string(nil), files...)}
+	}
+
 	fileServer := http.StripPrefix(r.path, http.FileServer(fs))
 	handler := func(c *Context) error {
 		fileServer.ServeHTTP(c.res, c.req)
@@ -247,11 +542,224 @@ func (r *RouteBuilder) StaticFS(fs http.FileSystem) *RouteBuilder {
 	return r
 }
 
+// Mount registers the routes of any method to delegate the requests
+// under the route path to the standard http handler, which will strip
+// the route path as the prefix from the request path.
+//
+// If the request path is the route path itself, the stripped path is "/".
+func (r *RouteBuilder) Mount(handler http.Handler) *RouteBuilder {
+	if strings.Contains(r.path, ":") || strings.Contains(r.path, "*") {
+		panic(errors.New("URL parameters cannot be used when mounting a handler"))
+	}
+
+	h := FromHTTPHandler(stripPrefix(strings.TrimSuffix(r.path, "/"), handler))
+	r.addRoute("", path.Join(r.path, "/"), h, "")
+	r.addRoute("", path.Join(r.path, "/*"), h, "")
+	return r
+}
+
+// stripPrefix is the same as http.StripPrefix, but uses "/"
+// instead of the empty path after stripping the prefix.
+func stripPrefix(prefix string, handler http.Handler) http.Handler {
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "" {
+			r.URL.Path = "/" // The request has been copied by http.StripPrefix.
+			r.URL.RawPath = ""
+		}
+		handler.ServeHTTP(w, r)
+	}))
+}
+
 // Static is the same as StaticFS, but listing the files for a directory.
 func (r *RouteBuilder) Static(dirpath string) *RouteBuilder {
 	return r.StaticFS(newOnlyFileFS(dirpath))
 }
 
+// DirEntry is the information of the file in the directory,
+// which is used to render the directory index by StaticWithListing.
+type DirEntry struct {
+	Name    string
+	Size    int64
+	IsDir   bool
+	ModTime time.Time
+}
+
+// DirIndex is the data to render the directory index by StaticWithListing.
+type DirIndex struct {
+	Path    string // The request path
+	Entries []DirEntry
+}
+
+// StaticWithListing is the same as Static, but renders the directory index
+// by the template with DirIndex when requesting a directory without any index
+// file in Ship.IndexFiles.
+//
+// If tmpl is nil, the listing is disabled and it returns ErrNotFound
+// for the directory without any index file.
+func (r *RouteBuilder) StaticWithListing(dirpath string, tmpl *template.Template) *RouteBuilder {
+	if strings.Contains(r.path, ":") || strings.Contains(r.path, "*") {
+		panic(errors.New("URL parameters cannot be used when serving a static file"))
+	}
+
+	fs := http.Dir(dirpath)
+	handler := func(c *Context) error {
+		return serveFileWithListing(c, fs, path.Clean("/"+c.Param("*")), tmpl)
+	}
+
+	r.addRoute("", path.Join(r.path, "/"), handler, http.MethodHead, http.MethodGet)
+	r.addRoute("", path.Join(r.path, "/*"), handler, http.MethodHead, http.MethodGet)
+	return r
+}
+
+// SPA registers the routes to serve the single-page application,
+// which serves the static assets in fsys normally, but falls back to
+// the file index, such as "index.html", for the paths without
+// the file extension, so that the client-side routing works.
+//
+// The missing assets under the prefix "/assets/" or with the file extension
+// are still responded with ErrNotFound.
+func (r *RouteBuilder) SPA(fsys http.FileSystem, index string) *RouteBuilder {
+	if strings.Contains(r.path, ":") || strings.Contains(r.path, "*") {
+		panic(errors.New("URL parameters cannot be used when serving a static file"))
+	} else if fsys == nil {
+		panic("RouteBuilder.SPA: the filesystem must not be nil")
+	} else if index == "" {
+		panic("RouteBuilder.SPA: the index file must not be empty")
+	}
+
+	index = path.Clean("/" + index)
+	handler := func(c *Context) error {
+		name := path.Clean("/" + c.Param("*"))
+		if ok, err := serveFile(c, fsys, name); ok || err != nil {
+			return err
+		} else if strings.HasPrefix(name, "/assets/") || path.Ext(name) != "" {
+			return ErrNotFound
+		}
+
+		if ok, err := serveFile(c, fsys, index); ok || err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+
+	r.addRoute("", path.Join(r.path, "/"), handler, http.MethodHead, http.MethodGet)
+	r.addRoute("", path.Join(r.path, "/*"), handler, http.MethodHead, http.MethodGet)
+	return r
+}
+
+// serveFile serves the regular file in fs and returns true,
+// or returns false if the file does not exist or is a directory.
+func serveFile(c *Context, fs http.FileSystem, name string) (ok bool, err error) {
+	f, err := fs.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, ErrInternalServerError.New(err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false, ErrInternalServerError.New(err)
+	} else if fi.IsDir() {
+		return false, nil
+	}
+
+	http.ServeContent(c.res, c.req, fi.Name(), fi.ModTime(), f)
+	return true, nil
+}
+
+func serveFileWithListing(c *Context, fs http.FileSystem, name string,
+	tmpl *template.Template) (err error) {
+	f, err := fs.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return ErrInternalServerError.New(err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return ErrInternalServerError.New(err)
+	} else if !fi.IsDir() {
+		http.ServeContent(c.res, c.req, fi.Name(), fi.ModTime(), f)
+		return nil
+	}
+
+	for _, file := range c.indexFiles() {
+		index, err := fs.Open(path.Join(name, file))
+		if err != nil {
+			continue
+		}
+
+		if ifi, err := index.Stat(); err == nil && !ifi.IsDir() {
+			defer index.Close()
+			http.ServeContent(c.res, c.req, ifi.Name(), ifi.ModTime(), index)
+			return nil
+		}
+		index.Close()
+	}
+
+	if tmpl == nil {
+		return ErrNotFound
+	}
+
+	fis, err := f.Readdir(-1)
+	if err != nil {
+		return ErrInternalServerError.New(err)
+	}
+	sort.Slice(fis, func(i, j int) bool { return fis[i].Name() < fis[j].Name() })
+
+	entries := make([]DirEntry, len(fis))
+	for i, fi := range fis {
+		entries[i] = DirEntry{
+			Name:    fi.Name(),
+			Size:    fi.Size(),
+			IsDir:   fi.IsDir(),
+			ModTime: fi.ModTime(),
+		}
+	}
+
+	buf := c.AcquireBuffer()
+	defer c.ReleaseBuffer(buf)
+	data := DirIndex{Path: c.req.URL.Path, Entries: entries}
+	if err = tmpl.Execute(buf, data); err != nil {
+		return ErrInternalServerError.New(err)
+	}
+	return c.Blob(http.StatusOK, MIMETextHTMLCharsetUTF8, buf.Bytes())
+}
+
+// indexFileFS replaces the file "index.html" opened by http.FileServer
+// for the directory with the first existing one of the index files.
+type indexFileFS struct {
+	fs      http.FileSystem
+	indexes []string
+}
+
+func (fs indexFileFS) Open(name string) (http.File, error) {
+	if path.Base(name) != "index.html" {
+		return fs.fs.Open(name)
+	}
+
+	dir := path.Dir(name)
+	for _, index := range fs.indexes {
+		f, err := fs.fs.Open(path.Join(dir, index))
+		if err != nil {
+			continue
+		}
+
+		// The index file must not be a directory.
+		if fi, err := f.Stat(); err == nil && !fi.IsDir() {
+			return f, nil
+		}
+		f.Close()
+	}
+	return nil, os.ErrNotExist
+}
+
 func newOnlyFileFS(root string) http.FileSystem {
 	return onlyFileFS{fs: http.Dir(root)}
 }
@@ -277,8 +785,15 @@ func (f noDirFile) Readdir(count int) ([]os.FileInfo, error) { return nil, nil }
 // Remove removes the route.
 //
 // If the method is "", it will remove all the routes associated with the path.
+// And the routes of the alias paths are also removed.
 func (r *RouteBuilder) Remove(method string) *RouteBuilder {
-	r.ship.DelRoutes(Route{Name: r.name, Path: r.path, Method: method})
+	routes := make([]Route, 0, len(r.paths)+1)
+	routes = append(routes, Route{Name: r.name, Path: r.path, Method: method})
+	for _, path := range r.paths {
+		routes = append(routes, Route{Path: path, Method: method})
+	}
+
+	r.ship.DelRoutes(routes...)
 	return r
 }
 
@@ -26,7 +26,7 @@ type RouteGroupBuilder struct {
 	mdwares []Middleware
 }
 
-func newRouteGroup(s *Ship, pprefix, prefix string,
+func newRouteGroup(s *Ship, pprefix, prefix string, data interface{},
 	mws ...Middleware) *RouteGroupBuilder {
 	if prefix = strings.TrimSuffix(prefix, "/"); len(prefix) == 0 {
 		prefix = "/"
@@ -38,14 +38,23 @@ func newRouteGroup(s *Ship, pprefix, prefix string,
 		ship:    s,
 		prefix:  strings.TrimSuffix(pprefix, "/") + prefix,
 		mdwares: append([]Middleware{}, mws...),
+		data:    data,
 	}
 }
 
-// Group returns a new route sub-group with the group prefix.
+// Group returns a new route sub-group with the group prefix,
+// which inherits the global middlewares and appends the given middlewares.
 //
 // If the prefix does not start with "/", it will add "/" as the prefix.
-func (s *Ship) Group(prefix string) *RouteGroupBuilder {
-	return newRouteGroup(s, s.Prefix, prefix, s.mws...)
+//
+// The prefix may contain the path parameters, such as "/tenants/:tenant",
+// which are available in all the routes of the group and its middlewares,
+// and are counted in the maximum number of the url parameters.
+func (s *Ship) Group(prefix string, middlewares ...Middleware) *RouteGroupBuilder {
+	mws := make([]Middleware, 0, len(s.mws)+len(middlewares))
+	mws = append(mws, s.mws...)
+	mws = append(mws, middlewares...)
+	return newRouteGroup(s, s.Prefix, prefix, nil, mws...)
 }
 
 // Ship returns the ship that the current group belongs to.
@@ -67,14 +76,15 @@ func (g *RouteGroupBuilder) Use(middlewares ...Middleware) *RouteGroupBuilder {
 	return g
 }
 
-// Group returns a new route sub-group.
+// Group returns a new route sub-group, which inherits the prefix,
+// the middlewares and the context data of the current group.
 //
 // If the prefix does not start with "/", it will add "/" as the prefix.
 func (g *RouteGroupBuilder) Group(prefix string, middlewares ...Middleware) *RouteGroupBuilder {
 	mws := make([]Middleware, 0, len(g.mdwares)+len(middlewares))
 	mws = append(mws, g.mdwares...)
 	mws = append(mws, middlewares...)
-	return newRouteGroup(g.ship, g.prefix, prefix, mws...)
+	return newRouteGroup(g.ship, g.prefix, prefix, g.data, mws...)
 }
 
 // Data sets the context data.
@@ -0,0 +1,113 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteGroupBuilder(t *testing.T) {
+	bs := bytes.NewBufferString("\n")
+	newMiddleware := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(c *Context) error {
+				bs.WriteString(name + " start\n")
+				err := next(c)
+				bs.WriteString(name + " end\n")
+				return err
+			}
+		}
+	}
+
+	router := New()
+	router.Use(newMiddleware("use"))
+
+	group := router.Group("/v1", newMiddleware("group1")).Data("data")
+	subgroup := group.Group("/sub", newMiddleware("group2"))
+	subgroup.Route("/route").GET(func(c *Context) error {
+		bs.WriteString(c.Route.Path + " " + c.Route.Data.(string) + "\n")
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/sub/route", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	const expected = `
+use start
+group1 start
+group2 start
+/v1/sub/route data
+group2 end
+group1 end
+use end
+`
+	if s := bs.String(); s != expected {
+		t.Errorf("expect '%s', but got '%s'", expected, s)
+	}
+}
+
+func TestRouteGroupBuilderWithParamPrefix(t *testing.T) {
+	validTenant := func(next Handler) Handler {
+		return func(c *Context) error {
+			tenant := c.Param("tenant")
+			if tenant != "t1" && tenant != "t2" {
+				return ErrNotFound
+			}
+			c.Data["tenant"] = tenant
+			return next(c)
+		}
+	}
+
+	router := New()
+	group := router.Group("/tenants/:tenant", validTenant)
+	group.Route("/users").GET(func(c *Context) error {
+		return c.Text(200, c.GetString("tenant")+":users")
+	})
+	group.Route("/users/:id").GET(func(c *Context) error {
+		return c.Text(200, c.GetString("tenant")+":user:"+c.Param("id"))
+	})
+	group.Route("/projects/:id").GET(func(c *Context) error {
+		return c.Text(200, c.GetString("tenant")+":project:"+c.Param("id"))
+	})
+	router.Route("/tenants/:id/info").GET(func(c *Context) error {
+		return c.Text(200, "info:"+c.Param("id"))
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{path: "/tenants/t1/users", code: 200, body: "t1:users"},
+		{path: "/tenants/t1/users/123", code: 200, body: "t1:user:123"},
+		{path: "/tenants/t2/projects/456", code: 200, body: "t2:project:456"},
+		{path: "/tenants/t3/users/123", code: 404},
+		{path: "/tenants/t3/info", code: 200, body: "info:t3"},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if rec.Code != test.code {
+			t.Errorf("%s: expect status code %d, but got %d", test.path, test.code, rec.Code)
+		} else if test.body != "" && rec.Body.String() != test.body {
+			t.Errorf("%s: expect body '%s', but got '%s'", test.path, test.body, rec.Body.String())
+		}
+	}
+}
@@ -15,11 +15,22 @@
 package ship
 
 import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
 	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/xgfone/ship/v5/router/echo"
 )
 
 func TestRoute(t *testing.T) {
@@ -305,3 +316,669 @@ func TestAllRouteMethods(t *testing.T) {
 		}
 	}
 }
+
+func TestTryAddRoutes(t *testing.T) {
+	router := New()
+	err := router.TryAddRoutes(
+		Route{Path: "/path1", Method: http.MethodGet, Handler: OkHandler()},
+		Route{Path: "path2", Method: http.MethodGet, Handler: OkHandler()},
+		Route{Path: "/path3", Method: http.MethodGet},
+	)
+	if errs, ok := err.(RouteErrors); !ok {
+		t.Errorf("expect RouteErrors, but got %T", err)
+	} else if len(errs) != 2 {
+		t.Errorf("expect %d route errors, but got %d", 2, len(errs))
+	} else if routes := router.Routes(); len(routes) != 0 {
+		t.Errorf("unexpected routes: %v", routes)
+	}
+
+	err = router.TryAddRoutes(
+		Route{Path: "/path1", Method: http.MethodGet, Handler: OkHandler()},
+		Route{Path: "/path2", Method: http.MethodPost, Handler: OkHandler()},
+	)
+	if err != nil {
+		t.Error(err)
+	} else if routes := router.Routes(); len(routes) != 2 {
+		t.Errorf("expect %d routes, but got %d", 2, len(routes))
+	}
+
+	router.Route("/path4").Name("path4").GET(func(c *Context) error { return c.Text(200, "old") })
+	err = router.TryAddRoutes(
+		Route{Path: "/path4", Method: http.MethodGet, Handler: OkHandler()},
+		Route{Path: "/path4", Method: http.MethodPut, Handler: OkHandler()},
+		Route{Path: "/path5", Method: http.MethodGet, Handler: OkHandler()},
+		Route{Path: "/:a/:b/:c/:d/:e", Method: http.MethodGet, Handler: OkHandler()},
+	)
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if routes := router.Routes(); len(routes) != 3 {
+		t.Errorf("expect %d routes, but got %d: %v", 3, len(routes), routes)
+	} else if path := router.Router.Path("path4"); path != "/path4" {
+		t.Errorf("expect the route name path '%s', but got '%s'", "/path4", path)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/path4", nil))
+	if body := rec.Body.String(); body != "old" {
+		t.Errorf("expect body '%s', but got '%s'", "old", body)
+	}
+}
+
+func TestRoutesHandler(t *testing.T) {
+	vhost := New()
+	vhost.Route("/vhost").GET(OkHandler())
+	vhosts := NewHostManagerHandler(nil)
+	vhosts.AddHost("www.example.com", vhost)
+
+	router := New()
+	router.Route("/path").Name("path").GET(OkHandler())
+	router.Route("/routes").GET(router.RoutesHandler(vhosts))
+
+	req := httptest.NewRequest(http.MethodGet, "/routes", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var routes []HostRoute
+	if err := json.NewDecoder(rec.Body).Decode(&routes); err != nil {
+		t.Error(err)
+	} else if len(routes) != 3 {
+		t.Errorf("expect %d routes, but got %d", 3, len(routes))
+	} else {
+		sort.Slice(routes, func(i, j int) bool { return routes[i].Path < routes[j].Path })
+		if r := routes[0]; r.Name != "path" || r.Path != "/path" || r.Method != http.MethodGet || r.Host != "" {
+			t.Errorf("unexpected route: %v", r)
+		}
+		if r := routes[2]; r.Path != "/vhost" || r.Host != "www.example.com" {
+			t.Errorf("unexpected route: %v", r)
+		}
+	}
+}
+
+func TestMount(t *testing.T) {
+	router := New()
+	router.Mount("/admin", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: http.MethodGet, path: "/admin", body: "GET /"},
+		{method: http.MethodGet, path: "/admin/", body: "GET /"},
+		{method: http.MethodPost, path: "/admin/path/to", body: "POST /path/to"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if s := rec.Body.String(); s != test.body {
+			t.Errorf("expect body '%s', but got '%s'", test.body, s)
+		}
+	}
+}
+
+func TestAllowedMethods(t *testing.T) {
+	router := New()
+	router.Route("/path").GET(OkHandler()).DELETE(OkHandler())
+
+	methods := router.AllowedMethods("/path")
+	sort.Strings(methods)
+	if len(methods) != 2 || methods[0] != http.MethodDelete || methods[1] != http.MethodGet {
+		t.Errorf("unexpected methods: %v", methods)
+	}
+}
+
+func TestRouteBuilderMethodE(t *testing.T) {
+	router := New()
+	if err := router.Route("/path1").Name("name").MethodE(OkHandler(), http.MethodGet); err != nil {
+		t.Error(err)
+	}
+
+	err := router.Route("/path2").Name("name").MethodE(OkHandler(), http.MethodGet)
+	if _, ok := err.(RouteError); !ok {
+		t.Errorf("expect a RouteError, but got '%v'", err)
+	}
+
+	err = router.Route("/:p1/:p2/:p3/:p4/:p5").MethodE(OkHandler(), http.MethodGet)
+	if re, ok := err.(RouteError); !ok || re.Err != errTooManyURLParams {
+		t.Errorf("expect the error '%v', but got '%v'", errTooManyURLParams, err)
+	}
+}
+
+func TestDelRouteByName(t *testing.T) {
+	router := New()
+	router.Route("/path/:id").Name("name").GET(OkHandler()).POST(OkHandler())
+	if url := router.Router.Path("name", 123); url != "/path/123" {
+		t.Errorf("expect url '%s', but got '%s'", "/path/123", url)
+	}
+
+	router.DelRoutes(Route{Name: "name"})
+	if url := router.Router.Path("name", 123); url != "" {
+		t.Errorf("unexpected url '%s'", url)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/path/123", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expect status code %d, but got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestStaticWithListing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ship")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "b.txt"), []byte("bb"), 0644)
+
+	tmpl := template.Must(template.New("").Parse(
+		`{{.Path}}:{{range .Entries}} {{.Name}}{{if .IsDir}}/{{else}}({{.Size}}){{end}}{{end}}`))
+
+	router := New()
+	router.Route("/static").StaticWithListing(dir, tmpl)
+	router.Route("/nolist").StaticWithListing(dir, nil)
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{path: "/static/a.txt", code: 200, body: "a"},
+		{path: "/static/", code: 200, body: "/static/: a.txt(1) b.txt(2) sub/"},
+		{path: "/static/sub/", code: 200, body: "/static/sub/:"},
+		{path: "/static/none", code: 404},
+		{path: "/nolist/b.txt", code: 200, body: "bb"},
+		{path: "/nolist/", code: 404},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != test.code {
+			t.Errorf("%s: expect status code %d, but got %d", test.path, test.code, rec.Code)
+		} else if body := rec.Body.String(); test.body != "" && body != test.body {
+			t.Errorf("%s: expect body '%s', but got '%s'", test.path, test.body, body)
+		}
+	}
+}
+
+func TestRouteBuilderSPA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ship")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Mkdir(filepath.Join(dir, "assets"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("index"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "assets", "app.js"), []byte("app"), 0644)
+
+	router := New()
+	router.Route("/app").SPA(http.Dir(dir), "index.html")
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{path: "/app/", code: 200, body: "index"},
+		{path: "/app/assets/app.js", code: 200, body: "app"},
+		{path: "/app/users/123", code: 200, body: "index"},
+		{path: "/app/assets", code: 200, body: "index"},
+		{path: "/app/assets/none", code: 404},
+		{path: "/app/none.css", code: 404},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != test.code {
+			t.Errorf("%s: expect status code %d, but got %d", test.path, test.code, rec.Code)
+		} else if body := rec.Body.String(); test.body != "" && body != test.body {
+			t.Errorf("%s: expect body '%s', but got '%s'", test.path, test.body, body)
+		}
+	}
+}
+
+func TestStaticHeadMatchesGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ship")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("index"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("abc"), 0644)
+
+	router := New()
+	router.Route("/static").Static(dir)
+	router.Route("/listing").StaticWithListing(dir, nil)
+	router.Route("/spa").SPA(http.Dir(dir), "index.html")
+
+	headers := []string{HeaderContentType, HeaderContentLength, HeaderLastModified}
+	paths := []string{"/static/a.txt", "/listing/a.txt", "/spa/a.txt", "/spa/page"}
+	for _, path := range paths {
+		get := httptest.NewRecorder()
+		router.ServeHTTP(get, httptest.NewRequest(http.MethodGet, path, nil))
+
+		head := httptest.NewRecorder()
+		router.ServeHTTP(head, httptest.NewRequest(http.MethodHead, path, nil))
+
+		if get.Code != 200 || head.Code != 200 {
+			t.Errorf("%s: expect status code 200, but got GET %d and HEAD %d",
+				path, get.Code, head.Code)
+			continue
+		} else if head.Body.Len() != 0 {
+			t.Errorf("%s: expect no HEAD body, but got '%s'", path, head.Body.String())
+		}
+
+		for _, key := range headers {
+			if g, h := get.Header().Get(key), head.Header().Get(key); g == "" || g != h {
+				t.Errorf("%s: header '%s' mismatch: GET '%s', HEAD '%s'", path, key, g, h)
+			}
+		}
+
+		lastModified := get.Header().Get(HeaderLastModified)
+		for _, method := range []string{http.MethodGet, http.MethodHead} {
+			req := httptest.NewRequest(method, path, nil)
+			req.Header.Set(HeaderIfModifiedSince, lastModified)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusNotModified {
+				t.Errorf("%s %s: expect status code 304, but got %d", method, path, rec.Code)
+			}
+		}
+	}
+}
+
+func TestIndexFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ship")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Mkdir(filepath.Join(dir, "htm"), 0755)
+	os.Mkdir(filepath.Join(dir, "both"), 0755)
+	os.Mkdir(filepath.Join(dir, "none"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "htm", "index.htm"), []byte("htm"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "both", "index.htm"), []byte("htm"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "both", "default.html"), []byte("default"), 0644)
+	os.MkdirAll(filepath.Join(dir, "subdir", "default.html"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "subdir", "index.htm"), []byte("htm"), 0644)
+
+	router := New()
+	router.IndexFiles = []string{"default.html", "index.htm"}
+	router.Route("/static").Static(dir)
+	router.Route("/listing").StaticWithListing(dir, nil)
+	router.Route("/file/*").GET(func(c *Context) error {
+		return c.File(filepath.Join(dir, c.Param("*")))
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{path: "/static/htm/", code: 200, body: "htm"},
+		{path: "/static/both/", code: 200, body: "default"},
+		{path: "/static/subdir/", code: 200, body: "htm"},
+		{path: "/listing/htm/", code: 200, body: "htm"},
+		{path: "/listing/both/", code: 200, body: "default"},
+		{path: "/listing/none/", code: 404},
+		{path: "/file/htm", code: 200, body: "htm"},
+		{path: "/file/both", code: 200, body: "default"},
+		{path: "/file/none", code: 404},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != test.code {
+			t.Errorf("%s: expect status code %d, but got %d", test.path, test.code, rec.Code)
+		} else if body := rec.Body.String(); test.body != "" && body != test.body {
+			t.Errorf("%s: expect body '%s', but got '%s'", test.path, test.body, body)
+		}
+	}
+}
+
+func TestRouteBuilderPaths(t *testing.T) {
+	var built int
+	mw := func(next Handler) Handler {
+		built++
+		return next
+	}
+
+	s := New()
+	s.Group("/v1").Route("/a").Name("a").Paths("/b", "/c").Use(mw).
+		GET(func(c *Context) error { return c.Text(200, c.Path()) })
+
+	if built != 1 {
+		t.Errorf("expect the middleware to be built once, but got %d", built)
+	}
+
+	for _, path := range []string{"/v1/a", "/v1/b", "/v1/c"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		s.ServeHTTP(rec, req)
+		if rec.Code != 200 {
+			t.Errorf("%s: expect status code %d, but got %d", path, 200, rec.Code)
+		} else if body := rec.Body.String(); body != path {
+			t.Errorf("expect body '%s', but got '%s'", path, body)
+		}
+	}
+
+	if url := s.Router.Path("a"); url != "/v1/a" {
+		t.Errorf("expect url '%s', but got '%s'", "/v1/a", url)
+	}
+
+	s.Group("/v1").Route("/a").Name("a").Paths("/b", "/c").RemoveGET()
+	if routes := s.Routes(); len(routes) != 0 {
+		t.Errorf("expect no routes, but got %v", routes)
+	}
+}
+
+func TestRouteBuilderAcceptsProduces(t *testing.T) {
+	s := New()
+	s.Route("/").Accepts(MIMEApplicationJSON).Produces(MIMEApplicationJSON, MIMETextPlain).
+		Method(func(c *Context) error {
+			return c.Text(200, c.Negotiate(MIMEApplicationJSON, MIMETextPlain))
+		}, http.MethodGet, http.MethodPost)
+
+	tests := []struct {
+		method string
+		ct     string
+		accept string
+		code   int
+		body   string
+	}{
+		{method: http.MethodGet, code: 200, body: MIMEApplicationJSON},
+		{method: http.MethodGet, accept: "text/*", code: 200, body: MIMETextPlain},
+		{method: http.MethodGet, accept: "text/html, */*;q=0.1", code: 200, body: MIMEApplicationJSON},
+		{method: http.MethodGet, accept: "text/html", code: 406},
+		{method: http.MethodPost, ct: MIMEApplicationJSONCharsetUTF8, code: 200, body: MIMEApplicationJSON},
+		{method: http.MethodPost, ct: MIMEApplicationXML, code: 415},
+	}
+
+	for i, test := range tests {
+		var body io.Reader
+		if test.ct != "" {
+			body = strings.NewReader("{}")
+		}
+
+		req := httptest.NewRequest(test.method, "/", body)
+		if test.ct != "" {
+			req.Header.Set(HeaderContentType, test.ct)
+		}
+		if test.accept != "" {
+			req.Header.Set(HeaderAccept, test.accept)
+		}
+
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != test.code {
+			t.Errorf("%d: expect status code %d, but got %d", i, test.code, rec.Code)
+		} else if test.body != "" && rec.Body.String() != test.body {
+			t.Errorf("%d: expect body '%s', but got '%s'", i, test.body, rec.Body.String())
+		}
+	}
+}
+
+func TestRouteBuilderDeprecated(t *testing.T) {
+	sunset := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := New()
+	s.Route("/old").Deprecated(sunset, "https://example.com/docs").GET(OkHandler())
+	s.Route("/zero").Deprecated(time.Time{}, "").GET(OkHandler())
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/old", nil))
+	if v := rec.Header().Get("Deprecation"); v != "true" {
+		t.Errorf("expect Deprecation '%s', but got '%s'", "true", v)
+	} else if v := rec.Header().Get("Sunset"); v != "Mon, 02 Jan 2023 03:04:05 GMT" {
+		t.Errorf("unexpected Sunset '%s'", v)
+	} else if v := rec.Header().Get(HeaderLink); v != `<https://example.com/docs>; rel="sunset"` {
+		t.Errorf("unexpected Link '%s'", v)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/zero", nil))
+	if v := rec.Header().Get("Deprecation"); v != "true" {
+		t.Errorf("expect Deprecation '%s', but got '%s'", "true", v)
+	} else if v := rec.Header().Get("Sunset"); v != "" {
+		t.Errorf("unexpected Sunset '%s'", v)
+	} else if v := rec.Header().Get(HeaderLink); v != "" {
+		t.Errorf("unexpected Link '%s'", v)
+	}
+}
+
+func TestRouteDataInMiddleware(t *testing.T) {
+	var globalData, routeData interface{}
+	s := New()
+	s.Use(func(next Handler) Handler {
+		return func(c *Context) error {
+			globalData = c.RouteData()
+			return next(c)
+		}
+	})
+
+	s.Route("/path").Data("scope:read").Use(func(next Handler) Handler {
+		return func(c *Context) error {
+			routeData = c.RouteData()
+			return next(c)
+		}
+	}).GET(OkHandler())
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/path", nil))
+	if globalData != "scope:read" {
+		t.Errorf("global middleware: expect route data '%v', but got '%v'", "scope:read", globalData)
+	}
+	if routeData != "scope:read" {
+		t.Errorf("route middleware: expect route data '%v', but got '%v'", "scope:read", routeData)
+	}
+}
+
+func TestRouteWildcardParam(t *testing.T) {
+	s := New()
+	s.Route("/files/*").GET(func(c *Context) error { return c.Text(200, c.Param("*")) })
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/a/b/c", nil))
+	if body := rec.Body.String(); body != "a/b/c" {
+		t.Errorf("expect '%s', but got '%s'", "a/b/c", body)
+	}
+
+	s = New()
+	s.Router = echo.NewRouter(&echo.Config{WildcardLeadingSlash: true})
+	s.Route("/files/*").GET(func(c *Context) error { return c.Text(200, c.Param("*")) })
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/a/b/c", nil))
+	if body := rec.Body.String(); body != "/a/b/c" {
+		t.Errorf("expect '%s', but got '%s'", "/a/b/c", body)
+	}
+}
+
+func testDebugMiddleware(next Handler) Handler { return next }
+
+func TestRouteMiddlewares(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	s := New()
+	s.Debug = true
+	s.Logger = NewLoggerFromWriter(buf, "")
+	s.Use(testDebugMiddleware)
+	s.Route("/path").Use(NewMiddleware(nil)).GET(OkHandler())
+
+	routes := s.Routes()
+	expect := []string{"ship.testDebugMiddleware", "ship.NewMiddleware"}
+	if len(routes) != 1 {
+		t.Fatalf("expect 1 route, but got %d", len(routes))
+	} else if !reflect.DeepEqual(routes[0].Middlewares, expect) {
+		t.Errorf("expect middlewares %v, but got %v", expect, routes[0].Middlewares)
+	}
+
+	log := "middlewares=[ship.testDebugMiddleware, ship.NewMiddleware]"
+	if !strings.Contains(buf.String(), log) {
+		t.Errorf("missing the debug log: %s", buf.String())
+	}
+}
+
+func TestRouteBuilderMatch(t *testing.T) {
+	isV2 := func(c *Context) bool { return c.GetReqHeader("X-Version") == "2" }
+	isV3 := func(c *Context) bool { return c.GetReqHeader("X-Version") == "3" }
+	textHandler := func(s string) Handler {
+		return func(c *Context) error { return c.Text(200, s) }
+	}
+
+	s := New()
+	s.Route("/path").Match(isV2).Data("v2").GET(func(c *Context) error {
+		return c.Text(200, "%v", c.RouteData())
+	})
+	s.Route("/path").GET(textHandler("v1"))
+	s.Route("/path").Match(isV3).GET(textHandler("v3"))
+	s.Route("/only").Match(isV2).GET(textHandler("only"))
+
+	check := func(path, version string, code int, body string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Version", version)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != code {
+			t.Errorf("%s %s: expect status code %d, but got %d", path, version, code, rec.Code)
+		} else if body != "" && rec.Body.String() != body {
+			t.Errorf("%s %s: expect body '%s', but got '%s'", path, version, body, rec.Body.String())
+		}
+	}
+
+	check("/path", "1", 200, "v1")
+	check("/path", "2", 200, "v2")
+	check("/path", "3", 200, "v3")
+	check("/only", "2", 200, "only")
+	check("/only", "1", 404, "")
+
+	if routes := s.Routes(); len(routes) != 4 {
+		t.Errorf("expect 4 routes, but got %d", len(routes))
+	}
+
+	var datas []interface{}
+	s.WalkRoutes(func(r Route) bool {
+		if r.Path == "/path" {
+			datas = append(datas, r.Data)
+		}
+		return true
+	})
+	if expect := []interface{}{"v2", nil, nil}; !reflect.DeepEqual(datas, expect) {
+		t.Errorf("expect the route data %v, but got %v", expect, datas)
+	}
+
+	s.DelRoute(Route{Path: "/path", Method: http.MethodGet})
+	s.Route("/path").GET(textHandler("new"))
+	check("/path", "2", 200, "new")
+
+	s.URLParamMaxNum = 1
+	if err := s.AddRoute(Route{Path: "/:a/:b", Method: http.MethodGet,
+		Handler: textHandler("ab"), matcher: isV2}); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if _, ok := s.guards[routeGuardKey("/:a/:b", http.MethodGet)]; ok {
+		t.Errorf("unexpected the guards of the failed route")
+	}
+}
+
+func TestRouteBuilderHost(t *testing.T) {
+	textHandler := func(s string) Handler {
+		return func(c *Context) error { return c.Text(200, s) }
+	}
+
+	s := New()
+	s.Route("/path").Host("a.example.com").GET(textHandler("a"))
+	s.Route("/path").Host("B.example.com").GET(textHandler("b"))
+	s.Route("/only").Host("*.example.com").GET(textHandler("sub"))
+	s.AddRoutes(Route{Path: "/path", Method: http.MethodPost, Host: "a.example.com",
+		Handler: textHandler("post a")})
+
+	check := func(method, host, path string, code int, body string) {
+		req := httptest.NewRequest(method, path, nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != code {
+			t.Errorf("%s %s%s: expect status code %d, but got %d", method, host, path, code, rec.Code)
+		} else if body != "" && rec.Body.String() != body {
+			t.Errorf("%s %s%s: expect body '%s', but got '%s'", method, host, path, body, rec.Body.String())
+		}
+	}
+
+	check(http.MethodGet, "a.example.com", "/path", 200, "a")
+	check(http.MethodGet, "b.example.com:8080", "/path", 200, "b")
+	check(http.MethodGet, "c.example.com", "/path", 404, "")
+	check(http.MethodPost, "a.example.com", "/path", 200, "post a")
+	check(http.MethodPost, "b.example.com", "/path", 404, "")
+	check(http.MethodGet, "x.example.com", "/only", 200, "sub")
+	check(http.MethodGet, "example.org", "/only", 404, "")
+
+	s.Route("/path").GET(textHandler("default"))
+	check(http.MethodGet, "c.example.com", "/path", 200, "default")
+	check(http.MethodGet, "a.example.com", "/path", 200, "a")
+}
+
+type noPriorityRouter struct{ Router }
+
+func TestRouteBuilderPriority(t *testing.T) {
+	s := New()
+	s.Route("/posts/new").GET(func(c *Context) error { return c.Text(200, "new") })
+	s.Route("/posts/:slug").Priority(1).GET(func(c *Context) error {
+		return c.Text(200, "slug:"+c.Param("slug"))
+	})
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/new", nil))
+	if body := rec.Body.String(); body != "slug:new" {
+		t.Errorf("expect '%s', but got '%s'", "slug:new", body)
+	}
+
+	s = New()
+	s.Router = noPriorityRouter{Router: s.Router}
+	err := s.AddRoute(Route{Path: "/path", Method: "GET", Handler: OkHandler(), Priority: 1})
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
+
+func TestShipWalkRoutes(t *testing.T) {
+	s1, s2 := New(), New()
+	s2.Router = noPriorityRouter{Router: s2.Router} // Not implement WalkRouter
+
+	for _, s := range []*Ship{s1, s2} {
+		s.Route("/a").GET(OkHandler())
+		s.Route("/b").Name("b").GET(OkHandler())
+		s.Route("/c").GET(OkHandler())
+
+		var route Route
+		var count int
+		s.WalkRoutes(func(r Route) bool {
+			count++
+			if r.Name == "b" {
+				route = r
+				return false
+			}
+			return true
+		})
+
+		if route.Path != "/b" {
+			t.Errorf("expect the route path '%s', but got '%s'", "/b", route.Path)
+		} else if count != 2 {
+			t.Errorf("expect to visit 2 routes, but got %d", count)
+		}
+	}
+}
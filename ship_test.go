@@ -16,11 +16,20 @@ package ship
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/xgfone/ship/v5/router"
 	"github.com/xgfone/ship/v5/router/echo"
 )
 
@@ -217,3 +226,535 @@ func TestMiddleware(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestAutoHEAD(t *testing.T) {
+	var rec *httptest.ResponseRecorder
+	router := New()
+	router.AutoHEAD = true
+	router.Route("/get").GET(func(c *Context) error {
+		c.SetRespHeader("X-Test", "test")
+		err := c.Text(201, "body")
+		if rec.Code != 201 {
+			t.Errorf("the response header is not written through")
+		}
+		return err
+	})
+	router.Route("/head").GET(OkHandler()).HEAD(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodHead, "/get", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != 201 {
+		t.Errorf("expect status code %d, but got %d", 201, rec.Code)
+	} else if body := rec.Body.String(); body != "" {
+		t.Errorf("unexpected body '%s'", body)
+	} else if v := rec.Header().Get("X-Test"); v != "test" {
+		t.Errorf("expect header X-Test '%s', but got '%s'", "test", v)
+	}
+
+	req = httptest.NewRequest(http.MethodHead, "/head", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expect status code %d, but got %d", http.StatusNoContent, rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodHead, "/notfound", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expect status code %d, but got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestAutoOptions(t *testing.T) {
+	router := New()
+	router.AutoOptions = true
+	router.Route("/path").GET(OkHandler()).POST(OkHandler())
+	router.Route("/options").PUT(OkHandler()).OPTIONS(func(c *Context) error {
+		return c.Text(200, "options")
+	})
+
+	tests := []struct {
+		path  string
+		code  int
+		allow string
+	}{
+		{path: "/path", code: 204, allow: "GET, POST, OPTIONS"},
+		{path: "/options", code: 200, allow: ""},
+		{path: "/notfound", code: 404, allow: ""},
+		{path: "*", code: 204, allow: "GET, POST, PUT, OPTIONS"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodOptions, test.path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != test.code {
+			t.Errorf("%s: expect status code %d, but got %d", test.path, test.code, rec.Code)
+		}
+
+		allow := strings.Split(rec.Header().Get(HeaderAllow), ", ")
+		expect := strings.Split(test.allow, ", ")
+		sort.Strings(allow)
+		sort.Strings(expect)
+		if strings.Join(allow, ",") != strings.Join(expect, ",") {
+			t.Errorf("%s: expect Allow '%s', but got '%s'", test.path, test.allow,
+				rec.Header().Get(HeaderAllow))
+		}
+	}
+}
+
+func TestShipSetErrorPage(t *testing.T) {
+	s := New()
+	s.NotFound = func(c *Context) error { return ErrNotFound }
+	s.SetErrorPage(http.StatusNotFound, func(c *Context, err error) error {
+		return c.HTML(http.StatusNotFound, "<h1>Not Found</h1>")
+	})
+	s.Route("/error").GET(func(c *Context) error {
+		return ErrBadRequest.Newf("bad request")
+	})
+
+	tests := []struct {
+		path   string
+		accept string
+		code   int
+		body   string
+	}{
+		{path: "/missing", accept: "text/html,*/*;q=0.8", code: 404, body: "<h1>Not Found</h1>"},
+		{path: "/missing", accept: "application/json", code: 404, body: "Not Found"},
+		{path: "/missing", accept: "", code: 404, body: "Not Found"},
+		{path: "/error", accept: "text/html", code: 400, body: "bad request"},
+	}
+
+	for i, test := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.accept != "" {
+			req.Header.Set(HeaderAccept, test.accept)
+		}
+
+		s.ServeHTTP(rec, req)
+		if rec.Code != test.code {
+			t.Errorf("%d: expect status code %d, but got %d", i, test.code, rec.Code)
+		} else if body := rec.Body.String(); body != test.body {
+			t.Errorf("%d: expect body '%s', but got '%s'", i, test.body, body)
+		}
+	}
+
+	// Use the default NotFound handler for the unknown path.
+	s = New()
+	s.SetErrorPage(http.StatusNotFound, func(c *Context, err error) error {
+		return c.HTML(http.StatusNotFound, "<h1>Not Found</h1>")
+	})
+
+	tests = tests[:3]
+	tests[1].body = `{"error":"not found"}`
+	for i, test := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.accept != "" {
+			req.Header.Set(HeaderAccept, test.accept)
+		}
+
+		s.ServeHTTP(rec, req)
+		if rec.Code != test.code {
+			t.Errorf("%d: expect status code %d, but got %d", i, test.code, rec.Code)
+		} else if body := rec.Body.String(); body != test.body {
+			t.Errorf("%d: expect body '%s', but got '%s'", i, test.body, body)
+		}
+	}
+}
+
+func TestIsClientDisconnect(t *testing.T) {
+	errs := []error{
+		context.Canceled,
+		context.DeadlineExceeded,
+		ErrInternalServerError.New(context.Canceled),
+		HTTPClientError{Err: ErrBadRequest.New(context.DeadlineExceeded)},
+	}
+	for i, err := range errs {
+		if !IsClientDisconnect(err) {
+			t.Errorf("%d: expect a client disconnect error: %v", i, err)
+		}
+	}
+
+	if IsClientDisconnect(nil) || IsClientDisconnect(ErrBadRequest) {
+		t.Errorf("unexpect a client disconnect error")
+	}
+
+	s := New()
+	s.Logger = NewLoggerFromWriter(bytes.NewBuffer(nil), "")
+	s.Route("/").GET(func(c *Context) error { return c.Request().Context().Err() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	s.ServeHTTP(rec, req)
+	if rec.Body.Len() != 0 {
+		t.Errorf("unexpect the response body: %s", rec.Body.String())
+	}
+
+	// The deadline of the server side, such as the database query timeout.
+	s.Route("/timeout").GET(func(c *Context) error {
+		return HTTPClientError{Err: context.DeadlineExceeded}
+	})
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/timeout", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expect status code %d, but got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
+
+func TestShipCleanPath(t *testing.T) {
+	s := New()
+	s.CleanPath = true
+	s.Route("/a/b").GET(func(c *Context) error { return c.Text(200, c.Path()) })
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/a//c/../b", nil)
+	s.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, rec.Code)
+	} else if body := rec.Body.String(); body != "/a//c/../b" {
+		t.Errorf("expect the original path '%s', but got '%s'", "/a//c/../b", body)
+	}
+
+	s.CleanPath = false
+	s = s.Clone("", nil)
+	s.Route("/a/b").GET(func(c *Context) error { return c.Text(200, c.Path()) })
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != 404 {
+		t.Errorf("expect status code %d, but got %d", 404, rec.Code)
+	}
+}
+
+func TestShipWithContextValue(t *testing.T) {
+	type ctxkey string
+
+	s := New().WithContextValue(ctxkey("db"), "dbhandle")
+	s.Route("/global").GET(func(c *Context) error {
+		ctx := c.Request().Context()
+		return c.Text(200, "%v|%v", ctx.Value(ctxkey("db")), ctx.Value(ctxkey("route")))
+	})
+	s.Route("/route").ContextValue(ctxkey("route"), "value").GET(func(c *Context) error {
+		ctx := c.Request().Context()
+		return c.Text(200, "%v|%v", ctx.Value(ctxkey("db")), ctx.Value(ctxkey("route")))
+	})
+
+	for path, expect := range map[string]string{
+		"/global": "dbhandle|<nil>",
+		"/route":  "dbhandle|value",
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		s.ServeHTTP(rec, req)
+		if body := rec.Body.String(); body != expect {
+			t.Errorf("%s: expect '%s', but got '%s'", path, expect, body)
+		}
+	}
+}
+
+func TestShipMaxPathLength(t *testing.T) {
+	s := New()
+	s.MaxPathLength = 16
+	s.Route("/*").GET(OkHandler())
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/"+strings.Repeat("a", 1024), nil)
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestURITooLong {
+		t.Errorf("expect status code %d, but got %d", http.StatusRequestURITooLong, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/short", nil)
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expect status code %d, but got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestShipOnError(t *testing.T) {
+	errDomain := errors.New("record not found")
+
+	s := New()
+	s.OnError(func(c *Context, err error) error {
+		if err == errDomain {
+			return ErrNotFound.New(err)
+		}
+		return err
+	})
+	s.OnError(func(c *Context, err error) error {
+		if se, ok := err.(HTTPServerError); ok && se.Code == http.StatusNotFound {
+			c.SetRespHeader("X-Error", "mapped")
+		}
+		return err
+	})
+	s.Route("/domain").GET(func(c *Context) error { return errDomain })
+	s.Route("/other").GET(func(c *Context) error { return ErrBadRequest })
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/domain", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expect status code %d, but got %d", http.StatusNotFound, rec.Code)
+	} else if v := rec.Header().Get("X-Error"); v != "mapped" {
+		t.Errorf("expect header X-Error '%s', but got '%s'", "mapped", v)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/other", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expect status code %d, but got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+type testWrapError struct{ err error }
+
+func (e testWrapError) Error() string { return "wrap: " + e.err.Error() }
+func (e testWrapError) Unwrap() error { return e.err }
+
+type testValidationError struct{ Field string }
+
+func (e *testValidationError) Error() string { return "invalid field " + e.Field }
+
+type testCodeError struct{ Codes []int } // Uncomparable
+
+func (e testCodeError) Error() string        { return "code error" }
+func (e testCodeError) Is(target error) bool { return target == errCodeTarget }
+func (e testCodeError) As(target interface{}) bool {
+	if v, ok := target.(**testValidationError); ok {
+		*v = &testValidationError{Field: "code"}
+		return true
+	}
+	return false
+}
+
+var errCodeTarget = errors.New("code target")
+
+func TestShipMapError(t *testing.T) {
+	errNotExist := errors.New("not exist")
+
+	s := New()
+	s.MapError(errNotExist, http.StatusNotFound)
+	s.MapError(errCodeTarget, http.StatusConflict)
+	s.MapErrorType((*testValidationError)(nil), func(err error) HTTPServerError {
+		return ErrUnprocessableEntity.Newf("field: %s", err.(*testValidationError).Field)
+	})
+	s.MapErrorType((*interface{ Timeout() bool })(nil), func(err error) HTTPServerError {
+		return ErrStatusGatewayTimeout.New(err)
+	})
+
+	s.Route("/sentinel").GET(func(c *Context) error { return errNotExist })
+	s.Route("/wrapped").GET(func(c *Context) error { return testWrapError{errNotExist} })
+	s.Route("/type").GET(func(c *Context) error {
+		return testWrapError{&testValidationError{Field: "name"}}
+	})
+	s.Route("/iface").GET(func(c *Context) error { return &net.DNSError{Err: "i/o timeout", Name: "host", IsTimeout: true} })
+	s.Route("/other").GET(func(c *Context) error { return errors.New("other") })
+	s.Route("/is").GET(func(c *Context) error { return testWrapError{testCodeError{}} })
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/sentinel", 404, "not exist"},
+		{"/wrapped", 404, "wrap: not exist"},
+		{"/type", 422, "field: name"},
+		{"/iface", 504, "lookup host: i/o timeout"},
+		{"/other", 500, ""},
+		{"/is", 409, "wrap: code error"},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if rec.Code != test.code {
+			t.Errorf("%s: expect status code %d, but got %d", test.path, test.code, rec.Code)
+		} else if body := rec.Body.String(); body != test.body {
+			t.Errorf("%s: expect body '%s', but got '%s'", test.path, test.body, body)
+		}
+	}
+
+	typ := reflect.TypeOf((*testValidationError)(nil))
+	if e, ok := asErrorType(testWrapError{testCodeError{}}, typ); !ok {
+		t.Errorf("expect to match the error type by the method As")
+	} else if ve, ok := e.(*testValidationError); !ok || ve.Field != "code" {
+		t.Errorf("unexpected error '%v'", e)
+	}
+}
+
+func TestShipOnRouteMatched(t *testing.T) {
+	var results []bool
+	s := New()
+	s.OnRouteMatched = func(c *Context, matched bool, dur time.Duration) {
+		if dur < 0 {
+			t.Errorf("unexpected duration %s", dur)
+		}
+		results = append(results, matched)
+	}
+	s.Route("/path").GET(OkHandler())
+
+	for _, path := range []string{"/path", "/missing"} {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if expect := []bool{true, false}; !reflect.DeepEqual(results, expect) {
+		t.Errorf("expect %v, but got %v", expect, results)
+	}
+}
+
+func TestShipFallback(t *testing.T) {
+	s := New()
+	s.NotFound = func(c *Context) error { return c.Text(http.StatusNotFound, "notfound") }
+	s.Fallback = func(c *Context) error {
+		if strings.HasPrefix(c.Path(), "/legacy/") {
+			return c.Text(http.StatusOK, "legacy")
+		}
+		return ErrNotFound
+	}
+	s.Route("/path").GET(OkHandler())
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/path", 200, "OK"},
+		{"/legacy/path", 200, "legacy"},
+		{"/missing", 404, "notfound"},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if rec.Code != test.code {
+			t.Errorf("%s: expect status code %d, but got %d", test.path, test.code, rec.Code)
+		} else if body := rec.Body.String(); body != test.body {
+			t.Errorf("%s: expect body '%s', but got '%s'", test.path, test.body, body)
+		}
+	}
+}
+
+func TestShipStrictContextReset(t *testing.T) {
+	var leaked *Context
+	var leakedData map[string]interface{}
+
+	s := New()
+	s.StrictContextReset = true
+	s.Route("/users/:id").GET(func(c *Context) error {
+		if leaked == nil {
+			leaked, leakedData = c, c.Data
+		} else if c == leaked {
+			t.Errorf("the context is reused")
+		}
+
+		c.Data["id"] = c.Param("id")
+		return c.NoContent(200)
+	})
+
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/2", nil))
+
+	if len(leaked.Data) != 0 {
+		t.Errorf("expect the empty data, but got %v", leaked.Data)
+	} else if len(leakedData) != 0 {
+		t.Errorf("expect the empty data, but got %v", leakedData)
+	} else if leaked.pvalues[0] != "" {
+		t.Errorf("expect the empty url parameter, but got '%s'", leaked.pvalues[0])
+	}
+}
+
+func TestShipWithLockRouter(t *testing.T) {
+	s := New()
+	s.Router = router.NewLockRouter(s.Router)
+	s.Route("/static").GET(OkHandler())
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				rec := httptest.NewRecorder()
+				s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static", nil))
+				if rec.Code != http.StatusOK {
+					t.Errorf("expect status code %d, but got %d", http.StatusOK, rec.Code)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		path := fmt.Sprintf("/plugin/%d", i)
+		s.Route(path).GET(OkHandler())
+		if i%2 == 0 {
+			s.Route(path).RemoveGET()
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	var walked int
+	s.WalkRoutes(func(Route) bool { walked++; return walked < 10 })
+	if walked != 10 {
+		t.Errorf("expect to walk %d routes, but got %d", 10, walked)
+	}
+
+	if n := len(s.Routes()); n != 51 {
+		t.Errorf("expect %d routes, but got %d", 51, n)
+	}
+	if methods := s.AllowedMethods("/static"); len(methods) != 1 || methods[0] != http.MethodGet {
+		t.Errorf("unexpected allowed methods %v", methods)
+	}
+
+	s.Route("/posts/new").GET(OkHandler())
+	s.Route("/posts/:slug").Priority(1).GET(func(c *Context) error {
+		return c.Text(200, c.Param("slug"))
+	})
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/new", nil))
+	if body := rec.Body.String(); body != "new" {
+		t.Errorf("expect the priority route '%s', but got '%s'", "new", body)
+	}
+
+	// The wrapped router does not implement the interface MethodsRouter.
+	s = New()
+	s.Router = router.NewLockRouter(struct{ router.Router }{s.Router})
+	s.Route("/get").GET(OkHandler())
+	s.Route("/any").Any(OkHandler())
+	if methods := s.AllowedMethods("/get"); len(methods) != 1 || methods[0] != http.MethodGet {
+		t.Errorf("unexpected allowed methods %v", methods)
+	}
+	if methods := s.AllowedMethods("/any"); len(methods) != len(standardMethods) {
+		t.Errorf("unexpected allowed methods %v", methods)
+	}
+	if methods := s.AllowedMethods("/none"); len(methods) != 0 {
+		t.Errorf("unexpected allowed methods %v", methods)
+	}
+
+	// The wrapped router returns the handler of MethodNotAllowed.
+	s = New()
+	s.Router = router.NewLockRouter(struct{ router.Router }{
+		echo.NewRouter(&echo.Config{MethodNotAllowedHandler: OkHandler()}),
+	}, func(h interface{}) bool { _, ok := h.(Route); return ok })
+	s.Route("/get").GET(OkHandler())
+	if methods := s.AllowedMethods("/get"); len(methods) != 1 || methods[0] != http.MethodGet {
+		t.Errorf("unexpected allowed methods %v", methods)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shiptest supplies the helpers to test the handlers of ship.
+package shiptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/xgfone/ship/v5"
+)
+
+// Option is used to configure the test request.
+type Option func(*http.Request)
+
+// WithHeader returns an option to set the request header.
+func WithHeader(key, value string) Option {
+	return func(r *http.Request) { r.Header.Set(key, value) }
+}
+
+// WithBody returns an option to set the request body with the Content-Type.
+func WithBody(contentType string, body io.Reader) Option {
+	return func(r *http.Request) {
+		req := httptest.NewRequest(r.Method, r.URL.String(), body)
+		r.Body, r.ContentLength = req.Body, req.ContentLength
+		r.Header.Set(ship.HeaderContentType, contentType)
+	}
+}
+
+// WithJSON returns an option to set the request body to v encoded by JSON.
+//
+// It will panic if failing to encode v.
+func WithJSON(v interface{}) Option {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return WithBody(ship.MIMEApplicationJSON, bytes.NewReader(data))
+}
+
+// WithForm returns an option to set the request body to the encoded form.
+func WithForm(form url.Values) Option {
+	return WithBody(ship.MIMEApplicationForm, strings.NewReader(form.Encode()))
+}
+
+// Tester is used to send the test requests to the ship router.
+type Tester struct {
+	ship *ship.Ship
+}
+
+// New returns a new Tester with the ship router.
+func New(s *ship.Ship) *Tester {
+	if s == nil {
+		panic("shiptest.New: the ship router must not be nil")
+	}
+	return &Tester{ship: s}
+}
+
+// Request builds the test request with the options, serves it
+// by the ship router, and returns the response.
+func (t *Tester) Request(method, path string, opts ...Option) *Response {
+	req := httptest.NewRequest(method, path, nil)
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	rec := httptest.NewRecorder()
+	t.ship.ServeHTTP(rec, req)
+	return &Response{rec: rec}
+}
+
+// Response is the response of the test request.
+type Response struct {
+	rec *httptest.ResponseRecorder
+}
+
+// Recorder returns the underlying response recorder.
+func (r *Response) Recorder() *httptest.ResponseRecorder { return r.rec }
+
+// Code returns the status code of the response.
+func (r *Response) Code() int { return r.rec.Code }
+
+// Body returns the response body as string.
+func (r *Response) Body() string { return r.rec.Body.String() }
+
+// Bytes returns the response body as bytes.
+func (r *Response) Bytes() []byte { return r.rec.Body.Bytes() }
+
+// Header returns the value of the response header by the name.
+func (r *Response) Header(name string) string { return r.rec.Header().Get(name) }
+
+// Headers returns all the response headers.
+func (r *Response) Headers() http.Header { return r.rec.Header() }
+
+// JSON decodes the response body by JSON into v.
+func (r *Response) JSON(v interface{}) error {
+	return json.Unmarshal(r.rec.Body.Bytes(), v)
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shiptest
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/xgfone/ship/v5"
+)
+
+func TestTester(t *testing.T) {
+	s := ship.Default()
+	s.Route("/json").POST(func(c *ship.Context) error {
+		var v struct {
+			Name string `json:"name"`
+		}
+		if err := c.Bind(&v); err != nil {
+			return err
+		}
+
+		c.SetRespHeader("X-Name", c.GetReqHeader("X-Name"))
+		return c.JSON(201, map[string]string{"name": v.Name})
+	})
+	s.Route("/form").POST(func(c *ship.Context) error {
+		return c.Text(200, c.Form("name"))
+	})
+
+	tester := New(s)
+	resp := tester.Request(http.MethodPost, "/json",
+		WithJSON(map[string]string{"name": "abc"}),
+		WithHeader("X-Name", "xyz"))
+
+	var result map[string]string
+	if resp.Code() != 201 {
+		t.Errorf("expect status code %d, but got %d", 201, resp.Code())
+	} else if v := resp.Header("X-Name"); v != "xyz" {
+		t.Errorf("expect header '%s', but got '%s'", "xyz", v)
+	} else if err := resp.JSON(&result); err != nil {
+		t.Error(err)
+	} else if result["name"] != "abc" {
+		t.Errorf("expect name '%s', but got '%s'", "abc", result["name"])
+	}
+
+	resp = tester.Request(http.MethodPost, "/form", WithForm(url.Values{"name": {"abc"}}))
+	if resp.Code() != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, resp.Code())
+	} else if body := resp.Body(); body != "abc" {
+		t.Errorf("expect body '%s', but got '%s'", "abc", body)
+	}
+
+	resp = tester.Request(http.MethodGet, "/missing")
+	if resp.Code() != 404 {
+		t.Errorf("expect status code %d, but got %d", 404, resp.Code())
+	}
+}
@@ -15,10 +15,30 @@
 package ship
 
 import (
+	"io"
 	"net/http"
+	"net/url"
+	"path"
 	"strings"
+	"sync"
 )
 
+// CleanPath returns the canonical form of the url path p, which collapses
+// the multiple slashes, resolves "." and "..", and keeps the trailing slash.
+func CleanPath(p string) string {
+	if p == "" {
+		return "/"
+	} else if p[0] != '/' {
+		p = "/" + p
+	}
+
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
+
 // IsInteger reports whether s is the integer or not.
 func IsInteger(s string) bool {
 	if s == "" {
@@ -84,3 +104,77 @@ func validOptionalPort(port string) bool {
 	}
 	return true
 }
+
+// ParseQueryWithSemicolon parses the raw query like url.ParseQuery,
+// but also uses the semicolon ";" as the separator like "&",
+// and ignores the malformed pairs.
+func ParseQueryWithSemicolon(rawQuery string) url.Values {
+	query, _ := url.ParseQuery(strings.Replace(rawQuery, ";", "&", -1))
+	return query
+}
+
+// ParseQueryWithBrackets parses the raw query like url.ParseQuery,
+// but removes the suffix "[]" of the keys, such as "ids[]=1&ids[]=2"
+// which is equal to "ids=1&ids=2", and ignores the malformed pairs.
+func ParseQueryWithBrackets(rawQuery string) url.Values {
+	query, _ := url.ParseQuery(rawQuery)
+	for key, values := range query {
+		if strings.HasSuffix(key, "[]") && len(key) > 2 {
+			delete(query, key)
+			name := key[:len(key)-2]
+			query[name] = append(query[name], values...)
+		}
+	}
+	return query
+}
+
+// parseQueryInto is the same as url.ParseQuery, but parses the query
+// into the given values to reuse it and ignores the invalid pairs.
+//
+// Notice: it must keep the same result as url.ParseQuery,
+// which is checked by TestParseQueryInto.
+func parseQueryInto(values url.Values, query string) {
+	for query != "" {
+		var key string
+		if index := strings.IndexByte(query, '&'); index > -1 {
+			key, query = query[:index], query[index+1:]
+		} else {
+			key, query = query, ""
+		}
+
+		if key == "" || strings.IndexByte(key, ';') > -1 {
+			continue
+		}
+
+		var value string
+		if index := strings.IndexByte(key, '='); index > -1 {
+			key, value = key[:index], key[index+1:]
+		}
+
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			continue
+		}
+
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			continue
+		}
+
+		values[key] = append(values[key], value)
+	}
+}
+
+var copyBufferPool = sync.Pool{New: func() interface{} {
+	buf := make([]byte, 32*1024)
+	return &buf
+}}
+
+// CopyBuffer is the same as io.CopyBuffer, but uses the buffer
+// acquired from the pool instead of allocating a new one.
+func CopyBuffer(dst io.Writer, src io.Reader) (n int64, err error) {
+	buf := copyBufferPool.Get().(*[]byte)
+	n, err = io.CopyBuffer(dst, src, *buf)
+	copyBufferPool.Put(buf)
+	return
+}
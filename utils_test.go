@@ -16,6 +16,8 @@ package ship
 
 import (
 	"fmt"
+	"net/url"
+	"reflect"
 	"testing"
 )
 
@@ -70,3 +72,59 @@ func ExampleSplitHostPort() {
 	// Host: fe80::1122:3344:5566:7788, Port: #
 	// Host: fe80::1122:3344:5566:7788, Port: 80#
 }
+
+func TestCleanPath(t *testing.T) {
+	tests := map[string]string{
+		"":              "/",
+		"a/b":           "/a/b",
+		"/a//b":         "/a/b",
+		"/a/./b/":       "/a/b/",
+		"/a/../../b":    "/b",
+		"/static/../..": "/",
+		"//":            "/",
+	}
+
+	for path, expect := range tests {
+		if cleaned := CleanPath(path); cleaned != expect {
+			t.Errorf("'%s': expect '%s', but got '%s'", path, expect, cleaned)
+		}
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	query := ParseQueryWithSemicolon("a=1;b=2&c=3")
+	if a, b, c := query.Get("a"), query.Get("b"), query.Get("c"); a != "1" || b != "2" || c != "3" {
+		t.Errorf("unexpected query: %v", query)
+	}
+
+	query = ParseQueryWithBrackets("ids[]=1&ids[]=2&ids=3&name=abc")
+	if ids := query["ids"]; len(ids) != 3 {
+		t.Errorf("expect 3 ids, but got %v", ids)
+	} else if _, ok := query["ids[]"]; ok {
+		t.Errorf("unexpected the key 'ids[]'")
+	} else if name := query.Get("name"); name != "abc" {
+		t.Errorf("expect name '%s', but got '%s'", "abc", name)
+	}
+}
+
+func TestParseQueryInto(t *testing.T) {
+	values := make(url.Values)
+	for _, query := range []string{
+		"",
+		"a=1&b=2&b=3",
+		"a&b=&=c&&d=4",
+		"d=a+b&e=%zz&%zz=f",
+		"f=1;g=2&h=3",
+		"x=%E4%B8%AD&x=%2B",
+	} {
+		for key := range values {
+			delete(values, key)
+		}
+		parseQueryInto(values, query)
+
+		expect, _ := url.ParseQuery(query)
+		if !reflect.DeepEqual(values, expect) {
+			t.Errorf("%s: expect %v, but got %v", query, expect, values)
+		}
+	}
+}
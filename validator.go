@@ -14,6 +14,13 @@
 
 package ship
 
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
 // Validator is used to validate the data is valid or not.
 type Validator interface {
 	Validate(data interface{}) error
@@ -29,3 +36,59 @@ func (v ValidatorFunc) Validate(data interface{}) error { return v(data) }
 func NothingValidator() Validator { return ValidatorFunc(nothingValidator) }
 
 func nothingValidator(interface{}) error { return nil }
+
+// StructValidator is used to validate the struct by the struct tags,
+// such as *github.com/go-playground/validator.Validate.
+type StructValidator interface {
+	Struct(s interface{}) error
+}
+
+// NewTagValidator returns a new Validator based on the struct validator,
+// which validates the data by the struct tags, such as `validate:"required"`.
+//
+// formatError is used to format the validation error. If not given,
+// it is FormatValidationError by default.
+func NewTagValidator(v StructValidator, formatError ...func(error) error) Validator {
+	format := FormatValidationError
+	if len(formatError) > 0 && formatError[0] != nil {
+		format = formatError[0]
+	}
+
+	return ValidatorFunc(func(data interface{}) (err error) {
+		if err = v.Struct(data); err != nil {
+			err = format(err)
+		}
+		return
+	})
+}
+
+// FormatValidationError formats the validation error to ErrBadRequest
+// with the readable message.
+//
+// If err is a slice of the field errors, which have implemented
+// the interface { Field() string; Tag() string }, such as
+// github.com/go-playground/validator.ValidationErrors, the message is
+// made up of the field names and the failed tags. Or, use err.Error().
+func FormatValidationError(err error) error {
+	vf := reflect.ValueOf(err)
+	if vf.Kind() != reflect.Slice || vf.Len() == 0 {
+		return ErrBadRequest.New(err)
+	}
+
+	msgs := make([]string, 0, vf.Len())
+	for i, _len := 0, vf.Len(); i < _len; i++ {
+		fe, ok := vf.Index(i).Interface().(fieldError)
+		if !ok {
+			return ErrBadRequest.New(err)
+		}
+		msgs = append(msgs, fmt.Sprintf("field '%s' failed on the '%s' tag",
+			fe.Field(), fe.Tag()))
+	}
+
+	return ErrBadRequest.New(errors.New(strings.Join(msgs, "; ")))
+}
+
+type fieldError interface {
+	Field() string
+	Tag() string
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ship
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type testFieldError struct{ field, tag string }
+
+func (e testFieldError) Field() string { return e.field }
+func (e testFieldError) Tag() string   { return e.tag }
+
+type testFieldErrors []testFieldError
+
+func (es testFieldErrors) Error() string { return "validation errors" }
+
+type testStructValidator func(interface{}) error
+
+func (f testStructValidator) Struct(v interface{}) error { return f(v) }
+
+func TestTagValidator(t *testing.T) {
+	v := NewTagValidator(testStructValidator(func(interface{}) error {
+		return testFieldErrors{{field: "Name", tag: "required"}, {field: "Age", tag: "min"}}
+	}))
+
+	expect := "field 'Name' failed on the 'required' tag; field 'Age' failed on the 'min' tag"
+	if err := v.Validate(nil); err == nil {
+		t.Error("expect an error, but got nil")
+	} else if se, ok := err.(HTTPServerError); !ok {
+		t.Errorf("expect HTTPServerError, but got %T", err)
+	} else if se.Code != http.StatusBadRequest {
+		t.Errorf("expect status code %d, but got %d", http.StatusBadRequest, se.Code)
+	} else if msg := se.Error(); msg != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, msg)
+	}
+
+	v = NewTagValidator(testStructValidator(func(interface{}) error {
+		return errors.New("error")
+	}), func(err error) error { return ErrUnsupportedMediaType.New(err) })
+	if err := v.Validate(nil); err == nil {
+		t.Error("expect an error, but got nil")
+	} else if se, ok := err.(HTTPServerError); !ok || se.Code != 415 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -62,6 +62,28 @@ type HostManager interface {
 	MatchHost(host string) (matchedHost string, matchedHandler http.Handler)
 }
 
+// HostRoute is the route associated with the host.
+type HostRoute struct {
+	Host string `json:"host,omitempty" xml:"host,omitempty"`
+	Route
+}
+
+// HostRoutes returns the routes of all the hosts managed by hm.
+//
+// Only the host handler that has implemented the interface
+// { Routes() []Route }, such as *Ship, is collected.
+func HostRoutes(hm HostManager) (routes []HostRoute) {
+	routes = make([]HostRoute, 0, 16)
+	hm.Range(func(host string, handler http.Handler) {
+		if h, ok := handler.(interface{ Routes() []Route }); ok {
+			for _, r := range h.Routes() {
+				routes = append(routes, HostRoute{Host: host, Route: r})
+			}
+		}
+	})
+	return
+}
+
 type lockHostManager struct {
 	lock  sync.RWMutex
 	hosts HostManager
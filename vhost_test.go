@@ -67,3 +67,18 @@ func TestVHost(t *testing.T) {
 		t.Errorf("Body: expect '%s', got '%s'", "vhost2", s)
 	}
 }
+
+func TestHostRoutes(t *testing.T) {
+	vhosts := NewHostManagerHandler(nil)
+
+	vhost := New()
+	vhost.Route("/router").GET(OkHandler())
+	vhosts.AddHost("www.example.com", vhost)
+
+	routes := HostRoutes(vhosts)
+	if len(routes) != 1 {
+		t.Errorf("expect %d routes, but got %d", 1, len(routes))
+	} else if r := routes[0]; r.Host != "www.example.com" || r.Path != "/router" {
+		t.Errorf("unexpected host route: %+v", r)
+	}
+}